@@ -0,0 +1,128 @@
+package main
+
+// Project config trust. A .pathuni.yaml found in the project tree is only
+// layered once trusted with `pathuni allow`, which records its content hash,
+// so a repository cannot inject paths or run commands on its own and editing
+// a file revokes trust until it is allowed again.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// allowFile returns the file recording trusted project configs.
+func allowFile() string {
+	return filepath.Join(xdgBaseDir("XDG_DATA_HOME", ".local", "share"), "pathuni", "allow.json")
+}
+
+func readAllowList() map[string]string {
+	allowed := make(map[string]string)
+	data, err := os.ReadFile(allowFile())
+	if err != nil {
+		return allowed
+	}
+	if json.Unmarshal(data, &allowed) != nil {
+		return make(map[string]string)
+	}
+	return allowed
+}
+
+func writeAllowList(allowed map[string]string) error {
+	data, err := json.MarshalIndent(allowed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(allowFile(), data)
+}
+
+// fileHash returns the hex SHA-256 of a file's contents.
+func fileHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isAllowed reports whether path was allowed with its current contents.
+func isAllowed(path string) bool {
+	hash, err := fileHash(path)
+	if err != nil {
+		return false
+	}
+	return readAllowList()[path] == hash
+}
+
+var allowRevoke bool
+
+var allowCmd = &cobra.Command{
+	Use:         "allow [file]",
+	Short:       "Trust a .pathuni.yaml (default: nearest one)",
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAllow(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func runAllow(args []string) error {
+	var file string
+	if len(args) == 1 {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		file = abs
+		if info, err := os.Stat(file); err == nil && info.IsDir() {
+			if found, ok := projectConfigIn(file); ok {
+				file = found
+			} else {
+				file = filepath.Join(file, projectConfigName)
+			}
+		}
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		found, ok := findProjectConfig(cwd)
+		if !ok {
+			return fmt.Errorf("no %s found in current directory or parents", projectConfigName)
+		}
+		file = found
+	}
+
+	allowed := readAllowList()
+	if allowRevoke {
+		delete(allowed, file)
+		if err := writeAllowList(allowed); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked %s\n", file)
+		return nil
+	}
+
+	hash, err := fileHash(file)
+	if err != nil {
+		return err
+	}
+	if _, err := loadConfig(file); err != nil {
+		return err
+	}
+	allowed[file] = hash
+	if err := writeAllowList(allowed); err != nil {
+		return err
+	}
+	fmt.Printf("Allowed %s (sha256 %s)\n", file, hash[:12])
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+// Small on-disk cache for expensive lookups (command output and the like) so
+// shell startup stays fast. Entries are grouped in per-bucket JSON files under
+// $XDG_CACHE_HOME/pathuni and are only valid while their fingerprint matches.
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cacheEntry is a cached value along with the fingerprint it was computed for.
+type cacheEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Value       string `json:"value"`
+}
+
+// xdgBaseDir returns the directory named by the XDG environment variable env,
+// falling back to $HOME joined with fallback when unset or not absolute.
+func xdgBaseDir(env string, fallback ...string) string {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(append([]string{home}, fallback...)...)
+}
+
+// cacheFile returns the JSON file backing the given cache bucket.
+func cacheFile(bucket string) string {
+	return filepath.Join(xdgBaseDir("XDG_CACHE_HOME", ".cache"), "pathuni", bucket+".json")
+}
+
+func readCacheBucket(bucket string) map[string]cacheEntry {
+	entries := make(map[string]cacheEntry)
+	data, err := os.ReadFile(cacheFile(bucket))
+	if err != nil {
+		return entries
+	}
+	if json.Unmarshal(data, &entries) != nil {
+		return make(map[string]cacheEntry)
+	}
+	return entries
+}
+
+// cacheGet returns the cached value for key when it was stored with the same
+// fingerprint.
+func cacheGet(bucket, key, fingerprint string) (string, bool) {
+	entry, ok := readCacheBucket(bucket)[key]
+	if !ok || entry.Fingerprint != fingerprint {
+		return "", false
+	}
+	return entry.Value, true
+}
+
+// cachePut stores value for key. Failures are ignored: the cache is only an
+// optimisation and must never break PATH generation.
+func cachePut(bucket, key, fingerprint, value string) {
+	entries := readCacheBucket(bucket)
+	entries[key] = cacheEntry{Fingerprint: fingerprint, Value: value}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(cacheFile(bucket), data)
+}
+
+// writeFileAtomic writes data to file through a temporary file and a rename,
+// creating the parent directory as needed, so readers never see a partial
+// file. An existing file keeps its permissions.
+func writeFileAtomic(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+// Command-derived paths: entries such as {command: [go, env, GOPATH], suffix: /bin}
+// run a program directly (no shell) and use its trimmed stdout as the path.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds how long a path command may run before it is killed.
+var commandTimeout = 2 * time.Second
+
+// commandCacheBucket is the cache bucket holding command output.
+const commandCacheBucket = "commands"
+
+// commandEnvVars are the variables passed on to path commands. Everything
+// else in the environment (tokens, tool overrides) is withheld.
+var commandEnvVars = []string{"PATH", "HOME", "USER", "LOGNAME", "TMPDIR", "LANG", "LC_ALL",
+	"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME"}
+
+// commandEnv returns the minimal environment path commands run with.
+func commandEnv() []string {
+	var env []string
+	for _, name := range commandEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// lookupCommand resolves the program of a path command. Bare names are looked
+// up through PATH only; relative paths are rejected so a command can never be
+// picked up from the current directory.
+func lookupCommand(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		if !filepath.IsAbs(name) {
+			return "", fmt.Errorf("command %q must be a bare name or an absolute path", name)
+		}
+		return name, nil
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("command %q not found in PATH", name)
+	}
+	if !filepath.IsAbs(bin) {
+		return "", fmt.Errorf("command %q resolved to relative path %q", name, bin)
+	}
+	return bin, nil
+}
+
+// stderrExcerpt returns the first non-empty line of stderr, shortened for
+// display in dry-run output.
+func stderrExcerpt(stderr []byte) string {
+	for _, line := range strings.Split(string(stderr), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(line) > 80 {
+			line = line[:77] + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+// runPathCommand executes argv without a shell, in a minimal environment, and
+// returns its trimmed stdout. Results are cached by command line and the
+// binary's modification time.
+func runPathCommand(argv []string) (string, error) {
+	if len(argv) == 0 {
+		return "", errors.New("empty command")
+	}
+	bin, err := lookupCommand(argv[0])
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(bin)
+	if err != nil {
+		return "", fmt.Errorf("command %q: %v", argv[0], err)
+	}
+
+	key := strings.Join(argv, "\x00")
+	fingerprint := fmt.Sprintf("%s@%d", bin, info.ModTime().UnixNano())
+	if out, ok := cacheGet(commandCacheBucket, key, fingerprint); ok {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bin, argv[1:]...)
+	cmd.Env = commandEnv()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("timed out after %s", commandTimeout)
+		}
+		if excerpt := stderrExcerpt(stderr.Bytes()); excerpt != "" {
+			return "", fmt.Errorf("%v: %s", err, excerpt)
+		}
+		return "", err
+	}
+
+	out := strings.TrimSpace(stdout.String())
+	if out == "" {
+		return "", errors.New("command produced no output")
+	}
+	cachePut(commandCacheBucket, key, fingerprint, out)
+	return out, nil
+}
+
+// resolveEntryPath returns the filesystem path for entry. Plain entries are
+// expanded against the config vars and the environment; command entries run
+// their command and append Suffix. A non-nil SkipReason means no path could
+// be derived, in which case the returned string is the entry's display form.
+func resolveEntryPath(entry PathEntry, vars map[string]string) (string, *SkipReason) {
+	if len(entry.Command) == 0 {
+		expanded, err := expandPath(entry.Path, vars)
+		if err != nil {
+			return entry.Display(), expansionSkipReason(err)
+		}
+		return expanded, nil
+	}
+	out, err := runPathCommand(entry.Command)
+	if err != nil {
+		return entry.Display(), &SkipReason{Type: "command_failed", Detail: fmt.Sprintf("command failed: %v", err)}
+	}
+	return out + entry.Suffix, nil
+}
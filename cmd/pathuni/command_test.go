@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommand_RunPathCommand(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	out, err := runPathCommand([]string{"echo", "  /tmp/pathuni/usr/local  "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "/tmp/pathuni/usr/local" {
+		t.Errorf("expected trimmed stdout, got %q", out)
+	}
+	if _, err := os.Stat(cacheFile(commandCacheBucket)); err != nil {
+		t.Errorf("expected command cache to be written: %v", err)
+	}
+}
+
+func TestCommand_LookupRejectsRelativePaths(t *testing.T) {
+	if _, err := lookupCommand("./bin/tool"); err == nil {
+		t.Error("expected relative command path to be rejected")
+	}
+	if _, err := lookupCommand("pathuni-no-such-binary"); err == nil {
+		t.Error("expected unknown command to fail lookup")
+	}
+}
+
+func TestCommand_FailureIncludesStderr(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	_, err := runPathCommand([]string{"ls", "/pathuni-definitely-missing"})
+	if err == nil {
+		t.Fatal("expected failing command to return an error")
+	}
+	if !strings.Contains(err.Error(), "pathuni-definitely-missing") {
+		t.Errorf("expected stderr excerpt in error, got %q", err.Error())
+	}
+}
+
+func TestCommand_CacheHitSkipsExecution(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// `false` always fails, so a successful result proves the cache was used
+	bin, err := exec.LookPath("false")
+	if err != nil {
+		t.Skip("false not available")
+	}
+	info, err := os.Stat(bin)
+	if err != nil {
+		t.Fatalf("stat %s: %v", bin, err)
+	}
+	fingerprint := fmt.Sprintf("%s@%d", bin, info.ModTime().UnixNano())
+	cachePut(commandCacheBucket, "false", fingerprint, "/cached/path")
+
+	out, err := runPathCommand([]string{"false"})
+	if err != nil || out != "/cached/path" {
+		t.Fatalf("expected cached value, got %q (err: %v)", out, err)
+	}
+
+	// A stale fingerprint must not be served
+	cachePut(commandCacheBucket, "false", "stale", "/cached/path")
+	if _, err := runPathCommand([]string{"false"}); err == nil {
+		t.Error("expected stale cache entry to be ignored")
+	}
+}
+
+func TestCommand_EntriesInEvaluation(t *testing.T) {
+	setupTestFilesystem(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	cfgPath := filepath.Join(t.TempDir(), "cmd.yaml")
+	cfgContent := `all:
+  paths:
+    - command: ["echo", "/tmp/pathuni/home/Pratt"]
+      suffix: "/.cargo/bin"
+    - command: ["ls", "/pathuni-definitely-missing"]
+      suffix: "/bin"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	valid, skipped, _, err := EvaluateConfig(cfgPath, "Linux", "bash", TagFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(valid) != 1 || valid[0] != "/tmp/pathuni/home/Pratt/.cargo/bin" {
+		t.Errorf("expected command-derived path to be included, got %v", valid)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("expected failed command to be skipped, got %v", skipped)
+	}
+
+	prune = "pathuni"
+	out := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "pathuni") })
+	if !strings.Contains(out, "[+] /tmp/pathuni/home/Pratt/.cargo/bin") {
+		t.Errorf("expected command-derived path in dry-run, got:\n%s", out)
+	}
+	if !strings.Contains(out, "[!] $(ls /pathuni-definitely-missing)/bin") || !strings.Contains(out, "command failed") {
+		t.Errorf("expected command_failed skip reason in dry-run, got:\n%s", out)
+	}
+}
+
+func TestCommand_FilteredAndMinimalEnv(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("PATHUNI_TEST_SECRET", "/leaked")
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	cfgPath := filepath.Join(dir, "cmd.yaml")
+	writeFile(t, cfgPath, `all:
+  paths:
+    - command: ["sh", "-c", "echo run >> `+runs+`; exit 1"]
+    - command: ["touch", "`+filepath.Join(dir, "excluded")+`"]
+      tags: [work]
+    - command: ["printenv", "PATHUNI_TEST_SECRET"]
+`)
+	tagsInclude, tagsExclude, prune = "", "work", "pathuni"
+	t.Cleanup(func() { tagsExclude = "" })
+
+	// The config is evaluated once per dry-run
+	out := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "full") })
+	if data, _ := os.ReadFile(runs); string(data) != "run\n" {
+		t.Errorf("expected the command to run once, got %q", data)
+	}
+	// Entries excluded by tags never run their command
+	if _, err := os.Stat(filepath.Join(dir, "excluded")); err == nil {
+		t.Errorf("command of an excluded entry was run")
+	}
+	if !strings.Contains(out, "[-] $(touch "+filepath.Join(dir, "excluded")+")") {
+		t.Errorf("expected excluded command entry in dry-run, got:\n%s", out)
+	}
+	// Commands do not see the rest of the environment
+	if strings.Contains(out, "/leaked") || !strings.Contains(out, "[!] $(printenv PATHUNI_TEST_SECRET)") {
+		t.Errorf("expected the environment to be withheld, got:\n%s", out)
+	}
+}
+
+func TestCommand_ExtractPathEntriesValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   map[string]interface{}
+		wantErr bool
+	}{
+		{"command only", map[string]interface{}{"command": []interface{}{"go", "env", "GOPATH"}, "suffix": "/bin"}, false},
+		{"path and command", map[string]interface{}{"path": "/x", "command": []interface{}{"go"}}, true},
+		{"empty command", map[string]interface{}{"command": []interface{}{}}, true},
+		{"suffix without command", map[string]interface{}{"path": "/x", "suffix": "/bin"}, true},
+		{"non-string argument", map[string]interface{}{"command": []interface{}{"go", 1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := extractPathEntries([]interface{}{tt.entry}, "test")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("extractPathEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 // extractPathEntries converts []interface{} to []PathEntry structs with both paths and tags
@@ -20,9 +18,39 @@ func extractPathEntries(paths []interface{}, context string) ([]PathEntry, error
 		case map[string]interface{}:
 			// PathEntry format
 			pathStr, hasPath := v["path"].(string)
-			if !hasPath {
+			command, err := extractCommand(v, context, i)
+			if err != nil {
+				return nil, err
+			}
+			if hasPath && command != nil {
+				return nil, fmt.Errorf("'path' and 'command' are mutually exclusive in %s at index %d", context, i)
+			}
+			if !hasPath && command == nil {
 				return nil, fmt.Errorf("missing 'path' field in %s at index %d", context, i)
 			}
+			var suffix string
+			if suffixInterface, hasSuffix := v["suffix"]; hasSuffix {
+				s, ok := suffixInterface.(string)
+				if !ok || command == nil {
+					return nil, fmt.Errorf("invalid 'suffix' in %s at index %d: expected string alongside 'command'", context, i)
+				}
+				suffix = s
+			}
+			if command != nil {
+				pathStr = commandDisplay(command, suffix)
+			}
+			position, err := extractPosition(v, context, i)
+			if err != nil {
+				return nil, err
+			}
+			priority, err := extractPriority(v, context, i)
+			if err != nil {
+				return nil, err
+			}
+			required, err := extractRequired(v, context, i)
+			if err != nil {
+				return nil, err
+			}
 			
 			var tags []string = nil  // Explicitly nil for inheritance
 			if tagsInterface, hasTags := v["tags"]; hasTags {
@@ -48,7 +76,7 @@ func extractPathEntries(paths []interface{}, context string) ([]PathEntry, error
 			}
 			// If no tags field exists, tags remains nil (for inheritance)
 			
-			result = append(result, PathEntry{Path: pathStr, Tags: tags})
+			result = append(result, PathEntry{Path: pathStr, Tags: tags, Command: command, Suffix: suffix, Position: position, Priority: priority, Required: required})
 		default:
 			return nil, fmt.Errorf("invalid path entry in %s at index %d: expected string or object", context, i)
 		}
@@ -56,15 +84,62 @@ func extractPathEntries(paths []interface{}, context string) ([]PathEntry, error
 	return result, nil
 }
 
+// extractCommand reads the optional 'command' field of a path object. It
+// returns nil when the field is absent.
+func extractCommand(v map[string]interface{}, context string, i int) ([]string, error) {
+	commandInterface, hasCommand := v["command"]
+	if !hasCommand {
+		return nil, nil
+	}
+	argsSlice, ok := commandInterface.([]interface{})
+	if !ok || len(argsSlice) == 0 {
+		return nil, fmt.Errorf("invalid command in %s at index %d: expected non-empty array", context, i)
+	}
+	command := make([]string, 0, len(argsSlice))
+	for _, arg := range argsSlice {
+		argStr, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid command argument in %s at index %d: expected string", context, i)
+		}
+		command = append(command, argStr)
+	}
+	return command, nil
+}
+
+// commandDisplay renders a command entry the way a shell user would write it,
+// e.g. "$(go env GOPATH)/bin".
+func commandDisplay(command []string, suffix string) string {
+	return "$(" + strings.Join(command, " ") + ")" + suffix
+}
+
 type ShellConfig struct {
     IncludeSystemPaths bool `yaml:"include_system_paths,omitempty"`
     IncludeSystemPathsAs string `yaml:"include_system_paths_as,omitempty"` // "system" (default) or "pathuni"
+    SystemSources []string `yaml:"system_sources,omitempty"` // Sources read by include_system_paths
     Tags []string `yaml:"tags,omitempty"`
+    Paths []interface{} `yaml:"paths,omitempty"` // Extra paths for this shell only
 }
 
 type PathEntry struct {
-	Path string   `yaml:"path"`
-	Tags []string `yaml:"tags,omitempty"`
+	Path    string   `yaml:"path"`
+	Tags    []string `yaml:"tags,omitempty"`
+	Command []string `yaml:"command,omitempty"` // Run without a shell; trimmed stdout is the path
+	Suffix  string   `yaml:"suffix,omitempty"`  // Appended to the command output
+
+	Position PathPosition `yaml:"-"`                  // Placement relative to system entries
+	Priority int          `yaml:"priority,omitempty"` // Ordering within a position group (higher first)
+	Required bool         `yaml:"required,omitempty"` // Warn (and fail with --strict) when missing
+
+	Provider string `yaml:"-"` // Set for entries generated by a provider
+}
+
+// Display returns the entry as written in the config, used when no resolved
+// path is available (e.g. its command failed).
+func (pe *PathEntry) Display() string {
+	if len(pe.Command) > 0 {
+		return commandDisplay(pe.Command, pe.Suffix)
+	}
+	return pe.Path
 }
 
 // GetEffectiveTags returns the effective tags for this path entry.
@@ -101,19 +176,78 @@ func validateConfig(cfg *Config) error {
 		return err
 	}
 	
+	// Validate provider names
+	if err := validateProviders(cfg.All.Providers, "all.providers"); err != nil {
+		return err
+	}
+	if err := validateProviders(cfg.Linux.Providers, "linux.providers"); err != nil {
+		return err
+	}
+	if err := validateProviders(cfg.MacOS.Providers, "macos.providers"); err != nil {
+		return err
+	}
+	
+	// Validate remove patterns
+	if err := validateRemovePatterns(cfg.All.Remove, "all.remove"); err != nil {
+		return err
+	}
+	if err := validateRemovePatterns(cfg.Linux.Remove, "linux.remove"); err != nil {
+		return err
+	}
+	if err := validateRemovePatterns(cfg.MacOS.Remove, "macos.remove"); err != nil {
+		return err
+	}
+	
+	// Validate shell-specific settings
+	if err := validateShellConfigs(cfg.All, "all"); err != nil {
+		return err
+	}
+	if err := validateShellConfigs(cfg.Linux, "linux"); err != nil {
+		return err
+	}
+	if err := validateShellConfigs(cfg.MacOS, "macos"); err != nil {
+		return err
+	}
+	
+	for _, p := range []struct {
+		source, context string
+	}{{cfg.All.SystemSource, "all"}, {cfg.Linux.SystemSource, "linux"}, {cfg.MacOS.SystemSource, "macos"}} {
+		if !isValidSystemSource(p.source) {
+			return fmt.Errorf("invalid system_source '%s' in %s. Use 'env' or 'login_shell'", p.source, p.context)
+		}
+	}
+	
+	// Validate defaults and profiles
+	if cfg.Defaults.Extends != "" {
+		return fmt.Errorf("extends is not supported in defaults")
+	}
+	if err := validateProfileValues(cfg.Defaults, "defaults"); err != nil {
+		return err
+	}
+	if err := validateProfiles(cfg.Profiles); err != nil {
+		return err
+	}
+
+	// Validate variable names and references between variables
+	if err := validateVars(cfg.Vars); err != nil {
+		return err
+	}
+	
 	return nil
 }
 
 // SkipReason represents why a path was skipped in dry-run output
 type SkipReason struct {
-	Type   string // "tags", "hostname", "not_found"
+	Type   string // "tags", "hostname", "not_found", "timed_out", "command_failed", "unset_var", "invalid_var"
 	Detail string // "gaming = gaming", "mac,gaming (+1) != essential"
 }
 
 // SkippedPath represents a path that was skipped with reasons
 type SkippedPath struct {
-	Path    string
-	Reasons []SkipReason
+	Path     string
+	Reasons  []SkipReason
+	Required bool   // A required entry that passes tag filtering
+	Provider string // Set for provider entries, which dry-run lists per provider
 }
 
 // EvaluationResult represents the comprehensive result of path evaluation for dry-run
@@ -125,120 +259,84 @@ type EvaluationResult struct {
 
 // EvaluateConfigWithReasons returns detailed evaluation results with skip reasons for dry-run v2
 func EvaluateConfigWithReasons(configPath, platform, shell string, tagFilter TagFilter) (*EvaluationResult, error) {
-	data, err := os.ReadFile(configPath)
+	statuses, _, err := EvaluateConfigDetailed(configPath, platform, shell, tagFilter)
 	if err != nil {
-		return nil, fmt.Errorf("error reading config file: %v", err)
-	}
-
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("error parsing config file: %v", err)
-	}
-	
-	// Validate platform-level tags
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("config validation error: %v", err)
+		return nil, err
 	}
+	return evaluationResult(statuses), nil
+}
 
+// evaluationResult sorts evaluated entries, in order, into included and
+// skipped paths. Existence wins over tag filtering.
+func evaluationResult(statuses []PathStatus) *EvaluationResult {
 	result := &EvaluationResult{
 		IncludedPaths: []string{},
 		SkippedPaths:  []SkippedPath{},
-		TotalPaths:    0,
+		TotalPaths:    len(statuses),
 	}
-
-	// Helper function to process platform paths
-	processPlatformPaths := func(platformConfig PlatformConfig, platformName string) error {
-		entries, err := extractPathEntries(platformConfig.Paths, fmt.Sprintf("%s.paths", platformName))
-		if err != nil {
-			return err
+	for _, st := range statuses {
+		skip := func(reasons []SkipReason, required bool) {
+			result.SkippedPaths = append(result.SkippedPaths, SkippedPath{Path: st.Path, Reasons: reasons, Required: required, Provider: st.Provider})
 		}
-
-		for _, entry := range entries {
-			result.TotalPaths++
-			
-			// Check if path exists
-			if _, err := os.Stat(os.ExpandEnv(entry.Path)); os.IsNotExist(err) {
-				result.SkippedPaths = append(result.SkippedPaths, SkippedPath{
-					Path:    os.ExpandEnv(entry.Path),
-					Reasons: []SkipReason{{Type: "not_found", Detail: "not found"}},
-				})
-				continue
-			}
-
-			// Check tag filtering using effective tags (with platform inheritance)
-			effectiveTags := entry.GetEffectiveTags(platformConfig.Tags)
-			if skipReasons := getPathSkipReasons(effectiveTags, entry.IsExplicitlyTagged(), tagFilter); skipReasons != nil {
-				result.SkippedPaths = append(result.SkippedPaths, SkippedPath{
-					Path:    os.ExpandEnv(entry.Path),
-					Reasons: skipReasons,
-				})
-			} else {
-				result.IncludedPaths = append(result.IncludedPaths, os.ExpandEnv(entry.Path))
-			}
+		switch {
+		case st.Unresolved:
+			skip(st.Reasons, st.Required)
+		case st.TimedOut && !st.Exists:
+			skip([]SkipReason{{Type: "timed_out", Detail: "timed out"}}, st.Required)
+		case !st.Exists:
+			skip([]SkipReason{{Type: "not_found", Detail: "not found"}}, st.Required)
+		case !st.PassesFilter:
+			skip(st.Reasons, false)
+		default:
+			result.IncludedPaths = append(result.IncludedPaths, st.Path)
 		}
-		return nil
 	}
+	return result
+}
 
-	// Process "all" platform
-	if err := processPlatformPaths(config.All, "all"); err != nil {
-		return nil, err
+// renderIncludedPath renders an included path with its origin marker, or
+// [~] when its existence check timed out and the policy kept it.
+func renderIncludedPath(marker, path string) string {
+	if pathTimedOut(path) {
+		return fmt.Sprintf("  [~] %s (timed out, included)", path)
 	}
+	return fmt.Sprintf("  [%s] %s", marker, path)
+}
 
-    // Process platform-specific paths
-    switch platform {
-    case "macOS":
-        if err := processPlatformPaths(config.MacOS, "macos"); err != nil {
-            return nil, err
-        }
-        // Add PowerShell system paths as pathuni entries when configured
-        psEntries := getPowerShellPathEntries(shell, config.MacOS)
-        for _, entry := range psEntries {
-            // Check existence
-            if _, err := os.Stat(os.ExpandEnv(entry.Path)); os.IsNotExist(err) {
-                result.SkippedPaths = append(result.SkippedPaths, SkippedPath{Path: os.ExpandEnv(entry.Path), Reasons: []SkipReason{{Type: "not_found", Detail: "not found"}}})
-                continue
-            }
-            // Tag filtering
-            effective := entry.GetEffectiveTags(config.MacOS.Tags)
-            if skipReasons := getPathSkipReasons(effective, entry.IsExplicitlyTagged(), tagFilter); skipReasons != nil {
-                result.SkippedPaths = append(result.SkippedPaths, SkippedPath{Path: os.ExpandEnv(entry.Path), Reasons: skipReasons})
-            } else {
-                result.IncludedPaths = append(result.IncludedPaths, os.ExpandEnv(entry.Path))
-            }
-        }
-    case "Linux":
-        if err := processPlatformPaths(config.Linux, "linux"); err != nil {
-            return nil, err
-        }
-        psEntries := getPowerShellPathEntries(shell, config.Linux)
-        for _, entry := range psEntries {
-            if _, err := os.Stat(os.ExpandEnv(entry.Path)); os.IsNotExist(err) {
-                result.SkippedPaths = append(result.SkippedPaths, SkippedPath{Path: os.ExpandEnv(entry.Path), Reasons: []SkipReason{{Type: "not_found", Detail: "not found"}}})
-                continue
-            }
-            effective := entry.GetEffectiveTags(config.Linux.Tags)
-            if skipReasons := getPathSkipReasons(effective, entry.IsExplicitlyTagged(), tagFilter); skipReasons != nil {
-                result.SkippedPaths = append(result.SkippedPaths, SkippedPath{Path: os.ExpandEnv(entry.Path), Reasons: skipReasons})
-            } else {
-                result.IncludedPaths = append(result.IncludedPaths, os.ExpandEnv(entry.Path))
-            }
-        }
-    }
-
-	return result, nil
+// renderMissingSystemPath renders a system path dropped by system pruning.
+func renderMissingSystemPath(path string) string {
+	if pathTimedOut(path) {
+		return fmt.Sprintf("  [~] %s (timed out)", path)
+	}
+	return fmt.Sprintf("  [?] %s (not found)", path)
 }
 
 // renderSkippedPath renders a single skipped path with tree structure for reasons
 func renderSkippedPath(skipped SkippedPath) string {
 	// Special case: not_found gets single-line format
 	if len(skipped.Reasons) == 1 && skipped.Reasons[0].Type == "not_found" {
+		if skipped.Required {
+			return fmt.Sprintf("  [R] %s (required, not found)", skipped.Path)
+		}
 		return fmt.Sprintf("  [!] %s (not found)", skipped.Path)
 	}
+	if len(skipped.Reasons) == 1 && skipped.Reasons[0].Type == "timed_out" {
+		if skipped.Required {
+			return fmt.Sprintf("  [R] %s (required, timed out)", skipped.Path)
+		}
+		return fmt.Sprintf("  [~] %s (timed out)", skipped.Path)
+	}
 	
 	// Determine icon character based on reason type
 	iconChar := "-"
-	if len(skipped.Reasons) > 0 && skipped.Reasons[0].Type == "not_found" {
-		iconChar = "!"
+	if len(skipped.Reasons) > 0 {
+		switch skipped.Reasons[0].Type {
+		case "not_found", "command_failed", "unset_var", "invalid_var":
+			iconChar = "!"
+		}
+	}
+	if skipped.Required {
+		iconChar = "R"
 	}
 	
 	var result strings.Builder
@@ -255,6 +353,66 @@ func renderSkippedPath(skipped SkippedPath) string {
 	return strings.TrimSuffix(result.String(), "\n")
 }
 
+// withoutProviderIncluded drops provider entries from a dry-run list; they
+// are shown grouped by provider instead (see printProviderGroups).
+func withoutProviderIncluded(entries []includedEntry) []includedEntry {
+	var out []includedEntry
+	for _, e := range entries {
+		if e.Provider == "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// withoutProviderSkipped is withoutProviderIncluded for skipped entries.
+func withoutProviderSkipped(skipped []SkippedPath) []SkippedPath {
+	var out []SkippedPath
+	for _, sp := range skipped {
+		if sp.Provider == "" {
+			out = append(out, sp)
+		}
+	}
+	return out
+}
+
+// printProviderGroups lists provider-generated entries grouped by provider.
+// pruneMissing mirrors the prune flag for the pathuni side: when false, missing
+// entries that pass tag filtering are still reported as included.
+func printProviderGroups(statuses []PathStatus, pruneMissing bool) {
+	var order []string
+	groups := make(map[string][]PathStatus)
+	for _, st := range statuses {
+		if st.Provider == "" {
+			continue
+		}
+		if _, ok := groups[st.Provider]; !ok {
+			order = append(order, st.Provider)
+		}
+		groups[st.Provider] = append(groups[st.Provider], st)
+	}
+	if len(order) == 0 {
+		return
+	}
+	fmt.Printf("Providers:\n")
+	for _, name := range order {
+		fmt.Printf("  %s\n", name)
+		for _, st := range groups[name] {
+			switch {
+			case st.Included || (!pruneMissing && st.PassesFilter):
+				fmt.Printf("    [+] %s\n", st.Path)
+			case st.TimedOut && pruneMissing:
+				fmt.Printf("    [~] %s (timed out)\n", st.Path)
+			case !st.Exists && pruneMissing:
+				fmt.Printf("    [!] %s (not found)\n", st.Path)
+			default:
+				fmt.Printf("    [-] %s\n", st.Path)
+			}
+		}
+	}
+	fmt.Printf("\n")
+}
+
 // PrintEvaluationReportV2 prints the enhanced dry-run output with tree structure
 func PrintEvaluationReportV2(configPath, platform, shell string, osInferred, shellInferred bool) error {
 	// Parse tag filters  
@@ -273,14 +431,14 @@ func PrintEvaluationReportV2(configPath, platform, shell string, osInferred, she
 	
 	// Print system info
 	if osInferred {
-		fmt.Printf("OS    : %s (detected)\n", platform)
+		fmt.Printf("OS     : %s (detected)\n", platform)
 	} else {
-		fmt.Printf("OS    : %s (specified)\n", platform)
+		fmt.Printf("OS     : %s (specified)\n", platform)
 	}
 	if shellInferred {
-		fmt.Printf("Shell : %s (detected)\n", shell)
+		fmt.Printf("Shell  : %s (detected)\n", shell)
 	} else {
-		fmt.Printf("Shell : %s (specified)\n", shell)
+		fmt.Printf("Shell  : %s (specified)\n", shell)
 	}
 	
 	fmt.Printf("\n")
@@ -325,61 +483,82 @@ func PrintEvaluationReportV2(configPath, platform, shell string, osInferred, she
 // includedEntry represents an included path along with its origin for dry-run
 // scope handling. Origin should be "pathuni" or "system".
 type includedEntry struct {
-    Path   string
-    Origin string
+    Path     string
+    Origin   string
+    Provider string // Set for provider entries, which dry-run lists per provider
 }
 
 // PrintDryRunReport prints dry-run output respecting the global scope flag.
 // It uses pathuni-first precedence when combining sources under scope=full.
 func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInferred bool, scope string) error {
+    _, err := printDryRunReport(configPath, platform, shell, osInferred, shellInferred, scope)
+    return err
+}
+
+// printDryRunReport is PrintDryRunReport returning the missing required
+// entries, so the config is evaluated only once.
+func printDryRunReport(configPath, platform, shell string, osInferred, shellInferred bool, scope string) ([]string, error) {
     // Header
     fmt.Printf("Evaluating: %s\n\n", configPath)
+    // List every config file considered when more than one was in play
+    if candidates := configCandidatesFor(configPath); len(candidates) > 1 {
+        fmt.Println("Config files:")
+        for _, c := range candidates {
+            fmt.Println(renderConfigCandidate(c))
+        }
+        fmt.Println()
+    }
     if osInferred {
-        fmt.Printf("OS    : %s (detected)\n", platform)
+        fmt.Printf("OS     : %s (detected)\n", platform)
     } else {
-        fmt.Printf("OS    : %s (specified)\n", platform)
+        fmt.Printf("OS     : %s (specified)\n", platform)
     }
     if shellInferred {
-        fmt.Printf("Shell : %s (detected)\n", shell)
+        fmt.Printf("Shell  : %s (detected)\n", shell)
     } else {
-        fmt.Printf("Shell : %s (specified)\n", shell)
+        fmt.Printf("Shell  : %s (specified)\n", shell)
+    }
+    fmt.Printf("Flags  : scope=%s, prune=%s, dedupe=%s\n", scope, prune, dedupe)
+    if activeProfile != "" {
+        fmt.Printf("Profile: %s (%s)\n", activeProfile, activeProfileSource)
     }
-    fmt.Printf("Flags : scope=%s, prune=%s\n\n", scope, prune)
+    if sources := formatSettingSources(); sources != "" {
+        fmt.Printf("Source : %s\n", sources)
+    }
+    fmt.Printf("\n")
 
     // Parse tag filters
     tagFilter, err := parseTagFlags(tagsInclude, tagsExclude)
     if err != nil {
-        return err
+        return nil, err
     }
 
     switch scope {
     case "pathuni":
         // Build included respecting prune for pathuni
         statuses, _, err := EvaluateConfigDetailed(configPath, platform, shell, tagFilter)
-        if err != nil { return err }
+        if err != nil { return nil, err }
+        includedEntries, dups := mergePlacedDetailed(placedFromStatuses(statuses, prune == "pathuni" || prune == "all"), nil)
         var includedPU []string
-        if prune == "pathuni" || prune == "all" {
-            for _, st := range statuses { if st.Included { includedPU = append(includedPU, st.Path) } }
-        } else {
-            for _, st := range statuses { if st.PassesFilter { includedPU = append(includedPU, st.Path) } }
-        }
-        if len(includedPU) > 0 {
-            if len(includedPU) == 1 { fmt.Printf("1 Included Path:\n") } else { fmt.Printf("%d Included Paths:\n", len(includedPU)) }
-            for _, p := range includedPU { fmt.Printf("  [+] %s\n", p) }
+        for _, e := range includedEntries { includedPU = append(includedPU, e.Path) }
+        if shown := withoutProviderIncluded(includedEntries); len(shown) > 0 {
+            if len(shown) == 1 { fmt.Printf("1 Included Path:\n") } else { fmt.Printf("%d Included Paths:\n", len(shown)) }
+            for _, e := range shown { fmt.Println(renderIncludedPath("+", e.Path)) }
             fmt.Printf("\n")
         }
         // Show pathuni skipped reasons only when pruning pathuni side
         skippedTotal := 0
         if prune == "pathuni" || prune == "all" {
-            result, err := EvaluateConfigWithReasons(configPath, platform, shell, tagFilter)
-            if err != nil { return err }
+            result := evaluationResult(statuses)
             skippedTotal = len(result.SkippedPaths)
-            if skippedTotal > 0 {
-                if skippedTotal == 1 { fmt.Printf("1 Skipped Path:\n") } else { fmt.Printf("%d Skipped Paths:\n", skippedTotal) }
-                for _, skipped := range result.SkippedPaths { fmt.Printf("%s\n", renderSkippedPath(skipped)) }
+            if shown := withoutProviderSkipped(result.SkippedPaths); len(shown) > 0 {
+                if len(shown) == 1 { fmt.Printf("1 Skipped Path:\n") } else { fmt.Printf("%d Skipped Paths:\n", len(shown)) }
+                for _, skipped := range shown { fmt.Printf("%s\n", renderSkippedPath(skipped)) }
                 fmt.Printf("\n")
             }
         }
+        printDuplicates(dups)
+        printProviderGroups(statuses, prune == "pathuni" || prune == "all")
         // Included summary (pathuni only) printed at the end
         if len(includedPU) == 1 {
             fmt.Printf("1 Pathuni path included in total\n")
@@ -395,10 +574,12 @@ func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInfe
         default:
             fmt.Printf("%d Pathuni paths skipped in total\n", skippedTotal)
         }
-        return nil
+        missing := missingRequired(statuses)
+        printMissingRequired(missing)
+        return missing, nil
     case "system":
-        sys, err := resolveSystemPathsContext(configPath, platform, shell)
-        if err != nil { return err }
+        sys, removedSys, err := resolveSystemPathsDetailed(configPath, platform, shell)
+        if err != nil { return nil, err }
         original := append([]string{}, sys...)
         var skippedSys []string
         if prune == "system" || prune == "all" {
@@ -409,32 +590,34 @@ func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInfe
         }
         if len(sys) > 0 {
             if len(sys) == 1 { fmt.Printf("1 Included Path:\n") } else { fmt.Printf("%d Included Paths:\n", len(sys)) }
-            for _, p := range sys { fmt.Printf("  [.] %s\n", p) }
+            for _, p := range sys { fmt.Println(renderIncludedPath(".", p)) }
             fmt.Printf("\n")
         }
         // Print skipped block first (details), then summaries at the end
-        if len(skippedSys) > 0 {
-            if len(skippedSys) == 1 { fmt.Printf("1 Skipped Path:\n") } else { fmt.Printf("%d Skipped Paths:\n", len(skippedSys)) }
-            for _, p := range skippedSys { fmt.Printf("  [?] %s (not found)\n", p) }
+        sysSkipped := len(removedSys) + len(skippedSys)
+        if sysSkipped > 0 {
+            if sysSkipped == 1 { fmt.Printf("1 Skipped Path:\n") } else { fmt.Printf("%d Skipped Paths:\n", sysSkipped) }
+            for _, r := range removedSys { fmt.Printf("%s\n", renderRemovedPath(r)) }
+            for _, p := range skippedSys { fmt.Println(renderMissingSystemPath(p)) }
             fmt.Printf("\n")
         }
+        printDuplicates(systemDuplicates(configPath, platform))
         // Summaries at the end
         if len(sys) == 1 { fmt.Printf("1 System path included in total\n") } else { fmt.Printf("%d System paths included in total\n", len(sys)) }
-        if len(skippedSys) == 0 {
+        if sysSkipped == 0 {
             fmt.Printf("0 Skipped paths\n")
-        } else if len(skippedSys) == 1 {
+        } else if sysSkipped == 1 {
             fmt.Printf("1 System path skipped in total\n")
         } else {
-            fmt.Printf("%d System paths skipped in total\n", len(skippedSys))
+            fmt.Printf("%d System paths skipped in total\n", sysSkipped)
         }
-        return nil
+        return nil, nil
     case "full":
         statuses, _, err := EvaluateConfigDetailed(configPath, platform, shell, tagFilter)
-        if err != nil { return err }
-        result, err := EvaluateConfigWithReasons(configPath, platform, shell, tagFilter)
-        if err != nil { return err }
-        sys, err := resolveSystemPathsContext(configPath, platform, shell)
-        if err != nil { return err }
+        if err != nil { return nil, err }
+        result := evaluationResult(statuses)
+        sys, removedSys, err := resolveSystemPathsDetailed(configPath, platform, shell)
+        if err != nil { return nil, err }
         originalSys := append([]string{}, sys...)
         var skippedSys []string
         if prune == "system" || prune == "all" {
@@ -443,24 +626,19 @@ func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInfe
             for _, p := range originalSys { if !m[p] { skippedSys = append(skippedSys, p) } }
             sys = filtered
         }
-        seen := make(map[string]bool)
-        var included []includedEntry
-        if prune == "pathuni" || prune == "all" {
-            for _, st := range statuses { if st.Included { if !seen[st.Path] { seen[st.Path] = true; included = append(included, includedEntry{Path: st.Path, Origin: "pathuni"}) } } }
-        } else {
-            for _, st := range statuses { if st.PassesFilter { if !seen[st.Path] { seen[st.Path] = true; included = append(included, includedEntry{Path: st.Path, Origin: "pathuni"}) } } }
-        }
-        for _, p := range sys { if !seen[p] { seen[p] = true; included = append(included, includedEntry{Path: p, Origin: "system"}) } }
-        if len(included) > 0 {
-            if len(included) == 1 {
+        // Merge honouring entry positions (pathuni-first by default)
+        included, dups := mergePlacedDetailed(placedFromStatuses(statuses, prune == "pathuni" || prune == "all"), sys)
+        dups = append(systemDuplicates(configPath, platform), dups...)
+        if shown := withoutProviderIncluded(included); len(shown) > 0 {
+            if len(shown) == 1 {
                 fmt.Printf("1 Included Path:\n")
             } else {
-                fmt.Printf("%d Included Paths:\n", len(included))
+                fmt.Printf("%d Included Paths:\n", len(shown))
             }
-            for _, e := range included {
+            for _, e := range shown {
                 marker := "."
                 if e.Origin == "pathuni" { marker = "+" }
-                fmt.Printf("  [%s] %s\n", marker, e.Path)
+                fmt.Println(renderIncludedPath(marker, e.Path))
             }
             fmt.Printf("\n")
         }
@@ -471,13 +649,17 @@ func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInfe
         } else {
             pathuniSkipped = nil
         }
-        skippedCount := len(pathuniSkipped) + len(skippedSys)
-        if skippedCount > 0 {
-            if skippedCount == 1 { fmt.Printf("1 Skipped Path:\n") } else { fmt.Printf("%d Skipped Paths:\n", skippedCount) }
-            for _, skipped := range pathuniSkipped { fmt.Printf("%s\n", renderSkippedPath(skipped)) }
-            for _, p := range skippedSys { fmt.Printf("  [?] %s (not found)\n", p) }
+        skippedCount := len(pathuniSkipped) + len(removedSys) + len(skippedSys)
+        shownSkipped := withoutProviderSkipped(pathuniSkipped)
+        if shownCount := len(shownSkipped) + len(removedSys) + len(skippedSys); shownCount > 0 {
+            if shownCount == 1 { fmt.Printf("1 Skipped Path:\n") } else { fmt.Printf("%d Skipped Paths:\n", shownCount) }
+            for _, skipped := range shownSkipped { fmt.Printf("%s\n", renderSkippedPath(skipped)) }
+            for _, r := range removedSys { fmt.Printf("%s\n", renderRemovedPath(r)) }
+            for _, p := range skippedSys { fmt.Println(renderMissingSystemPath(p)) }
             fmt.Printf("\n")
         }
+        printDuplicates(dups)
+        printProviderGroups(statuses, prune == "pathuni" || prune == "all")
         var pathuniCount, systemCount int
         for _, e := range included { if e.Origin == "pathuni" { pathuniCount++ } else { systemCount++ } }
         // Included summary: single-line when only one origin present; tree when both
@@ -492,7 +674,7 @@ func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInfe
         }
         totalSkipped := skippedCount
         puCount := len(pathuniSkipped)
-        sysCount := len(skippedSys)
+        sysCount := len(removedSys) + len(skippedSys)
         if totalSkipped == 0 {
             fmt.Printf("0 Skipped paths\n")
         } else if puCount > 0 && sysCount > 0 {
@@ -504,96 +686,46 @@ func PrintDryRunReport(configPath, platform, shell string, osInferred, shellInfe
         } else { // only system skipped
             if sysCount == 1 { fmt.Printf("1 System path skipped in total\n") } else { fmt.Printf("%d System paths skipped in total\n", sysCount) }
         }
-        return nil
+        missing := missingRequired(statuses)
+        printMissingRequired(missing)
+        return missing, nil
     }
-    return fmt.Errorf("invalid scope: %s", scope)
+    return nil, fmt.Errorf("invalid scope: %s", scope)
 }
 
 type PlatformConfig struct {
 	Tags       []string                `yaml:"tags,omitempty"`      // Platform-level tags for inheritance
 	Paths      []interface{}           `yaml:"paths,omitempty"`     // Can be string or PathEntry
-	PowerShell *ShellConfig            `yaml:"powershell,omitempty"`
+	Providers  []string                `yaml:"providers,omitempty"` // Built-in toolchain providers
+	Remove     []string                `yaml:"remove,omitempty"`    // System entries to drop (exact paths or globs)
+	PowerShell *ShellConfig            `yaml:"powershell,omitempty"` // Alias for shells.powershell
+	Shells     map[string]*ShellConfig `yaml:"shells,omitempty"`     // Per-shell settings, keyed by shell name
+	SystemSource string                `yaml:"system_source,omitempty"` // "env" (default) or "login_shell"
 }
 
 type Config struct {
 	All   PlatformConfig   `yaml:"all,omitempty"`
 	Linux PlatformConfig `yaml:"linux,omitempty"`
 	MacOS PlatformConfig `yaml:"macos,omitempty"`
-}
 
-func collectValidPaths(configPath, platform, shell string, tagFilter TagFilter) ([]string, int, error) {
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, 0, err
-	}
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, 0, err
-	}
-	
-	// Validate platform-level tags
-	if err := validateConfig(&cfg); err != nil {
-		return nil, 0, fmt.Errorf("config validation error: %v", err)
-	}
+	Defaults Profile            `yaml:"defaults,omitempty"` // Flag defaults below profiles and environment
+	Profiles map[string]Profile `yaml:"profiles,omitempty"` // Named bundles of flag settings
 
-	var rawPaths []string
-	var totalSystemPaths int
-	
-	// Add All section paths
-	allEntries, err := extractPathEntries(cfg.All.Paths, "all section")
+	Vars map[string]string `yaml:"vars,omitempty"` // Variables usable as $name in paths, before the environment
+}
+
+// loadConfig reads, parses and validates the config file at configPath.
+func loadConfig(configPath string) (*Config, error) {
+	cfg, err := readConfigFiles(configPath)
 	if err != nil {
-		return nil, 0, err
-	}
-	for _, entry := range allEntries {
-		effectiveTags := entry.GetEffectiveTags(cfg.All.Tags)
-		if shouldIncludePath(effectiveTags, entry.IsExplicitlyTagged(), tagFilter) {
-			rawPaths = append(rawPaths, entry.Path)
-		}
-	}
-	
-	// Get platform-specific paths
-	switch platform {
-	case "Linux":
-		linuxEntries, err := extractPathEntries(cfg.Linux.Paths, "linux section")
-		if err != nil {
-			return nil, 0, err
-		}
-		for _, entry := range linuxEntries {
-			effectiveTags := entry.GetEffectiveTags(cfg.Linux.Tags)
-			if shouldIncludePath(effectiveTags, entry.IsExplicitlyTagged(), tagFilter) {
-				rawPaths = append(rawPaths, entry.Path)
-			}
-		}
-		shellPaths := getShellSpecificPaths(shell, cfg.Linux)
-		rawPaths = append(rawPaths, shellPaths...)
-		totalSystemPaths += countValidSystemPaths(shell, cfg.Linux)
-	case "macOS":
-		macosEntries, err := extractPathEntries(cfg.MacOS.Paths, "macos section")
-		if err != nil {
-			return nil, 0, err
-		}
-		for _, entry := range macosEntries {
-			effectiveTags := entry.GetEffectiveTags(cfg.MacOS.Tags)
-			if shouldIncludePath(effectiveTags, entry.IsExplicitlyTagged(), tagFilter) {
-				rawPaths = append(rawPaths, entry.Path)
-			}
-		}
-		shellPaths := getShellSpecificPaths(shell, cfg.MacOS)
-		rawPaths = append(rawPaths, shellPaths...)
-		totalSystemPaths += countValidSystemPaths(shell, cfg.MacOS)
+		return nil, err
 	}
-
-	var paths []string
-	for _, line := range rawPaths {
-		expanded := os.ExpandEnv(line)
-		if info, err := os.Stat(expanded); err == nil && info.IsDir() {
-			paths = append(paths, expanded)
-		}
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("config validation error: %v", err)
 	}
-	return paths, totalSystemPaths, nil
+	return cfg, nil
 }
 
-
 // PathStatus represents the status of a path after evaluation
 type PathStatus struct {
     Path   string
@@ -602,22 +734,34 @@ type PathStatus struct {
     Included bool // true if should be included after tag filtering
     // PassesFilter indicates whether tag filtering passes regardless of existence
     PassesFilter bool
+    // Provider names the built-in provider that generated the entry, if any
+    Provider string
+    // Position and Priority control placement relative to system entries
+    Position PathPosition
+    Priority int
+    // Required marks required entries that pass tag filtering
+    Required bool
+    // TimedOut marks entries whose existence check hit --stat-timeout
+    TimedOut bool
+    // Unresolved marks entries without a usable path: their command failed
+    // or, excluded by tags, was not run. Path is then the entry as written.
+    Unresolved bool
+    // Reasons explains why the entry is skipped by tag filtering, or why it
+    // could not be resolved
+    Reasons []SkipReason
 }
 
 // EvaluateConfigDetailed returns detailed path status for improved dry-run output
 func EvaluateConfigDetailed(configPath, platform, shell string, tagFilter TagFilter) (pathStatuses []PathStatus, systemPathsCount int, err error) {
-	data, err := os.ReadFile(configPath)
+	return evaluateConfigIn(configPath, "", platform, shell, tagFilter)
+}
+
+// evaluateConfigIn is EvaluateConfigDetailed with relative entries resolved
+// against baseDir instead of the working directory, when baseDir is set.
+func evaluateConfigIn(configPath, baseDir, platform, shell string, tagFilter TagFilter) (pathStatuses []PathStatus, systemPathsCount int, err error) {
+	cfg, err := loadConfig(configPath)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to read config: %w", err)
-	}
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, 0, fmt.Errorf("failed to parse yaml: %w", err)
-	}
-	
-	// Validate platform-level tags
-	if err := validateConfig(&cfg); err != nil {
-		return nil, 0, fmt.Errorf("config validation error: %w", err)
+		return nil, 0, err
 	}
 
 	var totalSystemPaths int
@@ -625,63 +769,103 @@ func EvaluateConfigDetailed(configPath, platform, shell string, tagFilter TagFil
 	// Helper function to process entries with platform tags
 	processEntries := func(entries []PathEntry, platformTags []string) {
 		for _, entry := range entries {
-			expanded := os.ExpandEnv(entry.Path)
-			resolved := filepath.Clean(expanded)
-			
-            // Check existence
-            info, err := os.Stat(resolved)
-            exists := (err == nil && info.IsDir())
-            
-            // Get effective tags (with platform inheritance)
-            effectiveTags := entry.GetEffectiveTags(platformTags)
-            
-            // Evaluate tag filtering independently from existence
-            passes := shouldIncludePath(effectiveTags, entry.IsExplicitlyTagged(), tagFilter)
-            included := exists && passes
-            
-            pathStatuses = append(pathStatuses, PathStatus{
-                Path:     resolved,
-                Tags:     effectiveTags,  // Store effective tags, not original
-                Exists:   exists,
-                Included: included,
-                PassesFilter: passes,
-            })
+			// Get effective tags (with platform inheritance)
+			effectiveTags := entry.GetEffectiveTags(platformTags)
+			tagReasons := getPathSkipReasons(effectiveTags, entry.IsExplicitlyTagged(), tagFilter)
+			status := PathStatus{
+				Tags:     effectiveTags, // Store effective tags, not original
+				Reasons:  tagReasons,
+				Provider: entry.Provider,
+				Position: entry.Position,
+				Priority: entry.Priority,
+				Required: entry.Required && tagReasons == nil,
+			}
+			// An anchor that cannot be expanded is never found, so the entry
+			// is appended like one whose anchor is not on the system PATH
+			if anchor := status.Position.Anchor; anchor != "" {
+				status.Position.Anchor, _ = expandOrSkip("position anchor", anchor, cfg.Vars)
+			}
+
+			// Resolve the path. Commands of entries excluded by tags are never
+			// run; entries without a usable path never pass the filter.
+			if len(entry.Command) > 0 && tagReasons != nil {
+				status.Path, status.Unresolved = entry.Display(), true
+			} else if expanded, failure := resolveEntryPath(entry, cfg.Vars); failure != nil {
+				status.Path, status.Unresolved = expanded, true
+				status.Reasons = []SkipReason{*failure}
+			} else {
+				if baseDir != "" && !filepath.IsAbs(expanded) {
+					expanded = filepath.Join(baseDir, expanded)
+				}
+				status.Path = filepath.Clean(expanded)
+				status.PassesFilter = tagReasons == nil
+			}
+
+			// Existence is checked for all entries at once below
+			pathStatuses = append(pathStatuses, status)
 		}
 	}
 	
     // Add All section paths
-    entries, pathErr := extractPathEntries(cfg.All.Paths, "all section")
+    entries, pathErr := platformEntries(cfg.All, "all section", platform)
 	if pathErr != nil {
 		return nil, 0, fmt.Errorf("failed to parse config: %w", pathErr)
 	}
 	processEntries(entries, cfg.All.Tags)
+	shellEntries, pathErr := getShellPathEntries(shell, cfg.All, "all")
+	if pathErr != nil {
+		return nil, 0, fmt.Errorf("failed to parse config: %w", pathErr)
+	}
+	processEntries(shellEntries, cfg.All.Tags)
 	
 	// Get platform-specific paths
 	switch platform {
     case "Linux":
-        entries, pathErr := extractPathEntries(cfg.Linux.Paths, "linux section")
+        entries, pathErr := platformEntries(cfg.Linux, "linux section", platform)
         if pathErr != nil {
             return nil, 0, fmt.Errorf("failed to parse config: %w", pathErr)
         }
         processEntries(entries, cfg.Linux.Tags)
         
-        // Add PowerShell system paths as pathuni entries when configured
-        psEntries := getPowerShellPathEntries(shell, cfg.Linux)
-        processEntries(psEntries, cfg.Linux.Tags)
+        // Add the shell's own paths, and system paths classified as pathuni
+        shellEntries, pathErr := getShellPathEntries(shell, cfg.Linux, "linux")
+        if pathErr != nil {
+            return nil, 0, fmt.Errorf("failed to parse config: %w", pathErr)
+        }
+        processEntries(shellEntries, cfg.Linux.Tags)
         totalSystemPaths += countValidSystemPaths(shell, cfg.Linux)
         
     case "macOS":
-        entries, pathErr := extractPathEntries(cfg.MacOS.Paths, "macos section")
+        entries, pathErr := platformEntries(cfg.MacOS, "macos section", platform)
         if pathErr != nil {
             return nil, 0, fmt.Errorf("failed to parse config: %w", pathErr)
         }
         processEntries(entries, cfg.MacOS.Tags)
         
-        // Add PowerShell system paths as pathuni entries when configured
-        psEntries := getPowerShellPathEntries(shell, cfg.MacOS)
-        processEntries(psEntries, cfg.MacOS.Tags)
+        // Add the shell's own paths, and system paths classified as pathuni
+        shellEntries, pathErr := getShellPathEntries(shell, cfg.MacOS, "macos")
+        if pathErr != nil {
+            return nil, 0, fmt.Errorf("failed to parse config: %w", pathErr)
+        }
+        processEntries(shellEntries, cfg.MacOS.Tags)
         totalSystemPaths += countValidSystemPaths(shell, cfg.MacOS)
     }
+
+	// Check existence concurrently; results keep the entry order
+	var checked []int
+	var paths []string
+	for i, st := range pathStatuses {
+		if !st.Unresolved {
+			checked = append(checked, i)
+			paths = append(paths, st.Path)
+		}
+	}
+	for j, st := range statPaths(paths) {
+		i := checked[j]
+		pathStatuses[i].Exists = st.isDir()
+		pathStatuses[i].TimedOut = st.TimedOut
+		pathStatuses[i].Included = pathStatuses[i].Exists && pathStatuses[i].PassesFilter
+	}
 	
 	return pathStatuses, totalSystemPaths, nil
 }
@@ -735,12 +919,12 @@ func PrintEvaluationReport(configPath, platform, shell string, inferred bool) er
 
 	// Print header
 	fmt.Printf("Evaluating: %s\n\n", configPath)
-	fmt.Printf("OS    : %s\n", platform)
+	fmt.Printf("OS     : %s\n", platform)
 	label := "specified"
 	if inferred {
 		label = "detected"
 	}
-	fmt.Printf("Shell : %s (%s)\n\n", shell, label)
+	fmt.Printf("Shell  : %s (%s)\n\n", shell, label)
 
 	// Print included paths
 	if len(included) > 0 {
@@ -821,14 +1005,19 @@ func runDryRun() {
 		os.Exit(1)
 	}
 
-	if !shellIsValid(shellName) {
+	if !shellIsValid(shellName) && !shellIsConfigOnly(shellName) {
 		fmt.Fprintf(os.Stderr, "Unsupported shell '%s'. Supported shells: %s\n", shellName, strings.Join(shellNames(), ", "))
 		os.Exit(1)
 	}
+	if !isValidDedupe(dedupe) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid dedupe option '%s'. Use 'exact', 'clean', or 'realpath'\n", dedupe)
+		os.Exit(1)
+	}
 
-    err := PrintDryRunReport(configPath, osName, shellName, osInferred, shellInferred, scope)
+    missing, err := printDryRunReport(configPath, osName, shellName, osInferred, shellInferred, scope)
     if err != nil {
         fmt.Fprintf(os.Stderr, "Error: %v\n", err)
         os.Exit(1)
     }
+    exitOnMissingRequired(missing)
 }
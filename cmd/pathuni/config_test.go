@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -77,7 +78,6 @@ func TestConfig_YAMLParsing(t *testing.T) {
 
 func TestConfig_PlatformFiltering(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	configPath := filepath.Join("testdata", "platform_specific.yaml")
 	
@@ -131,7 +131,6 @@ func TestConfig_PlatformFiltering(t *testing.T) {
 
 func TestConfig_EnvironmentExpansion(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	configPath := filepath.Join("testdata", "env_vars.yaml")
 	
@@ -195,7 +194,6 @@ func TestConfig_EnvironmentExpansion(t *testing.T) {
 
 func TestConfig_PathValidation(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	configPath := filepath.Join("testdata", "missing_paths.yaml")
 	
@@ -215,24 +213,23 @@ func TestConfig_PathValidation(t *testing.T) {
 	
 	// Verify that valid paths actually exist
 	for _, path := range validPaths {
-		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		if info, err := fs.Stat(targetFS(), fsName(path)); err != nil || !info.IsDir() {
 			t.Errorf("Valid path %q does not exist or is not a directory", path)
 		}
 	}
 	
 	// Verify that skipped paths don't exist (or aren't directories)
 	for _, path := range skippedPaths {
-		if info, err := os.Stat(path); err == nil && info.IsDir() {
+		if info, err := fs.Stat(targetFS(), fsName(path)); err == nil && info.IsDir() {
 			t.Errorf("Skipped path %q actually exists and is a directory", path)
 		}
 	}
 }
 
-func TestConfig_CollectValidPaths(t *testing.T) {
+func TestConfig_EvaluateIncludesPaths(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
-	// Test the collectValidPaths function directly
+	// Test the evaluation behind init directly
 	configPath := filepath.Join("testdata", "valid_config.yaml")
 	
 	tests := []struct {
@@ -247,7 +244,8 @@ func TestConfig_CollectValidPaths(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			paths, systemCount, err := collectValidPaths(configPath, tt.platform, tt.shell, TagFilter{})
+			statuses, systemCount, err := EvaluateConfigDetailed(configPath, tt.platform, tt.shell, TagFilter{})
+			paths := includedPaths(statuses)
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -303,7 +301,7 @@ func TestConfig_ErrorHandling(t *testing.T) {
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := collectValidPaths(tt.configPath, tt.platform, tt.shell, TagFilter{})
+			_, _, err := EvaluateConfigDetailed(tt.configPath, tt.platform, tt.shell, TagFilter{})
 			
 			if tt.wantError && err == nil {
 				t.Error("Expected error but got none")
@@ -317,15 +315,15 @@ func TestConfig_ErrorHandling(t *testing.T) {
 
 func TestConfig_EdgeCases(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	// Test with empty platform (unsupported OS)
 	t.Run("empty platform", func(t *testing.T) {
 		configPath := filepath.Join("testdata", "valid_config.yaml")
-		paths, _, err := collectValidPaths(configPath, "", "bash", TagFilter{})
+		statuses, _, err := EvaluateConfigDetailed(configPath, "", "bash", TagFilter{})
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
+		paths := includedPaths(statuses)
 		
 		// Should only get paths from "all" section since platform is empty
 		if len(paths) == 0 {
@@ -412,7 +410,6 @@ func TestConfig_MixedYAMLFormat(t *testing.T) {
 
 func TestConfig_TagValidationErrors(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	tests := []struct {
 		name          string
@@ -516,7 +513,7 @@ macos:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tmpFile, err := os.CreateTemp("/tmp/pathuni/home/Pratt/.config/pathuni", "pathuni-validation-test-*.yaml")
+			tmpFile, err := os.CreateTemp(t.TempDir(), "pathuni-validation-test-*.yaml")
 			if err != nil {
 				t.Fatalf("Failed to create temp file: %v", err)
 			}
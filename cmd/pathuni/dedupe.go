@@ -0,0 +1,103 @@
+package main
+
+// Path deduplication. --dedupe decides when two PATH entries are the same
+// directory: "exact" compares the strings, "clean" (the default) compares
+// them after filepath.Clean so "/usr/local/bin/" and "/usr/local/./bin" match,
+// and "realpath" resolves symlinks so "/bin" matches "/usr/bin" on merged-/usr
+// systems. The first-seen spelling is always the one kept.
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// dedupe is the --dedupe mode.
+var dedupe string
+
+func isValidDedupe(mode string) bool {
+	switch mode {
+	case "exact", "clean", "realpath":
+		return true
+	default:
+		return false
+	}
+}
+
+// dedupeKey returns the comparison key of path under the current mode.
+// realpath resolves inside --root and is bounded by --stat-timeout like the
+// existence checks, reusing their result when the entry was already checked.
+// It falls back to the cleaned path for entries that cannot be resolved,
+// e.g. missing directories or a hanging mount.
+func dedupeKey(path string) string {
+	switch dedupe {
+	case "exact":
+		return path
+	case "realpath":
+		real, ok := cachedRealPath(path)
+		if !ok {
+			real = statWithTimeout(path).Real
+		}
+		if real != "" {
+			return real
+		}
+	}
+	return filepath.Clean(path)
+}
+
+// duplicatePath is an entry dropped because an earlier entry is the same
+// directory under the current --dedupe mode.
+type duplicatePath struct {
+	Path   string
+	Origin string        // "pathuni" or "system"
+	Of     includedEntry // The surviving entry
+}
+
+// renderDuplicatePath renders a dropped duplicate for dry-run output,
+// pointing at the surviving entry with its own marker.
+func renderDuplicatePath(d duplicatePath) string {
+	marker := "."
+	if d.Of.Origin == "pathuni" {
+		marker = "+"
+	}
+	return fmt.Sprintf("  [=] %s (duplicate of [%s] %s)", d.Path, marker, d.Of.Path)
+}
+
+// systemDuplicates returns the duplicate entries of the system PATH, which
+// are dropped before the system entries are merged.
+func systemDuplicates(configPath, platform string) []duplicatePath {
+	cfg, err := readConfigFiles(configPath)
+	if err != nil {
+		cfg = nil
+	}
+	current, err := baseSystemPath(cfg, platform)
+	if err != nil {
+		return nil
+	}
+	var dups []duplicatePath
+	seen := make(map[string]string)
+	for _, p := range current {
+		key := dedupeKey(p)
+		if first, ok := seen[key]; ok {
+			dups = append(dups, duplicatePath{Path: p, Origin: "system", Of: includedEntry{Path: first, Origin: "system"}})
+			continue
+		}
+		seen[key] = p
+	}
+	return dups
+}
+
+// printDuplicates lists the dropped duplicates for dry-run output.
+func printDuplicates(dups []duplicatePath) {
+	if len(dups) == 0 {
+		return
+	}
+	if len(dups) == 1 {
+		fmt.Printf("1 Duplicate Path:\n")
+	} else {
+		fmt.Printf("%d Duplicate Paths:\n", len(dups))
+	}
+	for _, d := range dups {
+		fmt.Println(renderDuplicatePath(d))
+	}
+	fmt.Printf("\n")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withDedupe(t *testing.T, mode string) {
+	t.Helper()
+	old := dedupe
+	dedupe = mode
+	t.Cleanup(func() { dedupe = old })
+}
+
+func TestDedupe_Modes(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "usr", "bin")
+	if err := os.MkdirAll(real, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	link := filepath.Join(dir, "bin")
+	if err := os.Symlink(filepath.Join("usr", "bin"), link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	in := []string{"/usr/local/bin", "/usr/local/bin/", "/usr/local/./bin", link, real, "/pathuni/missing", "/pathuni/missing/"}
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"exact", in},
+		{"clean", []string{"/usr/local/bin", link, real, "/pathuni/missing"}},
+		{"realpath", []string{"/usr/local/bin", link, "/pathuni/missing"}},
+	}
+	for _, tt := range tests {
+		withDedupe(t, tt.mode)
+		got := dedupePreserveOrder(in)
+		if strings.Join(got, ":") != strings.Join(tt.want, ":") {
+			t.Errorf("%s: got %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestDedupe_DryRunShowsDuplicates(t *testing.T) {
+	setupTestFilesystem(t)
+	withDedupe(t, "clean")
+	prune = "pathuni"
+
+	cfgPath := filepath.Join(t.TempDir(), "dedupe.yaml")
+	writeFile(t, cfgPath, `all:
+  paths:
+    - /tmp/pathuni/usr/local/bin
+    - /tmp/pathuni/usr/local/bin/
+    - /tmp/pathuni/bin/
+`)
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/bin:/tmp/pathuni/usr/bin/")
+
+	out := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "full") })
+	for _, want := range []string{
+		"Flags  : scope=full, prune=pathuni, dedupe=clean\n",
+		"3 Duplicate Paths:\n",
+		"  [=] /tmp/pathuni/usr/bin/ (duplicate of [.] /tmp/pathuni/usr/bin)\n",
+		"  [=] /tmp/pathuni/usr/local/bin (duplicate of [+] /tmp/pathuni/usr/local/bin)\n",
+		"  [=] /tmp/pathuni/bin (duplicate of [+] /tmp/pathuni/bin)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry-run missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "[.] /tmp/pathuni/bin") {
+		t.Errorf("system entry duplicating a pathuni entry should be dropped:\n%s", out)
+	}
+
+	// Differently spelled system entries survive in exact mode
+	withDedupe(t, "exact")
+	out = captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "full") })
+	if !strings.Contains(out, "  [.] /tmp/pathuni/usr/bin/\n") || strings.Contains(out, "usr/bin/ (duplicate") {
+		t.Errorf("exact mode should keep differently spelled entries:\n%s", out)
+	}
+}
+
+func TestDedupe_RealpathUnderRoot(t *testing.T) {
+	withDedupe(t, "realpath")
+	t.Cleanup(func() {
+		realPathsMu.Lock()
+		realPaths = map[string]string{}
+		realPathsMu.Unlock()
+	})
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "usr", "bin"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// Absolute, as in many images; on the host it would point elsewhere
+	if err := os.Symlink("/usr/bin", filepath.Join(root, "bin")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	rootDir = root
+	t.Cleanup(func() { rootDir = "" })
+
+	if got := dedupePreserveOrder([]string{"/usr/bin", "/bin"}); strings.Join(got, ":") != "/usr/bin" {
+		t.Errorf("/bin should resolve to /usr/bin inside the root, got %v", got)
+	}
+}
+
+func TestDedupe_RealpathTimesOut(t *testing.T) {
+	withDedupe(t, "realpath")
+	withHangingFS(t, 50*time.Millisecond, "include")
+	t.Cleanup(func() {
+		realPathsMu.Lock()
+		realPaths = map[string]string{}
+		realPathsMu.Unlock()
+	})
+
+	start := time.Now()
+	got := dedupePreserveOrder([]string{"/hang/nfs/bin", "/usr/bin", "/hang/nfs/bin/"})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("realpath waited %v on a hanging mount", elapsed)
+	}
+	if strings.Join(got, ":") != "/hang/nfs/bin:/usr/bin" {
+		t.Errorf("timed-out entries should fall back to the cleaned path, got %v", got)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+// Config file discovery and layering. Without --config, pathuni looks for a
+// system config under $XDG_CONFIG_DIRS, a user config under $XDG_CONFIG_HOME
+// and a project .pathuni.yaml in the current directory or its parents (only
+// once trusted with `pathuni allow`), and layers whatever it finds
+// (system -> user -> project). --config and
+// PATHUNI_CONFIG accept a single file or a list of files separated like PATH.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFileNames are the names accepted in a pathuni config directory, in
+// order of preference. Only the first one found in a directory is loaded.
+var configFileNames = []string{"config.yaml", "config.yml", "config.toml", "config.json", "my_paths.yaml"}
+
+// projectConfigName is the per-project config file looked up from the CWD.
+// The other encodings are accepted too, see projectConfigNames.
+const projectConfigName = ".pathuni.yaml"
+
+var projectConfigNames = []string{projectConfigName, ".pathuni.yml", ".pathuni.toml", ".pathuni.json"}
+
+// configCandidate is a config file considered during discovery.
+type configCandidate struct {
+	Path   string
+	Layer  string // "system", "user" or "project"
+	Loaded bool
+	Note   string // Why the file was not loaded
+}
+
+// xdgConfigDirs returns the system config directories, most important first.
+func xdgConfigDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/xdg"}
+	}
+	return dirs
+}
+
+// userConfigDir returns the user's pathuni config directory.
+func userConfigDir() string {
+	return filepath.Join(xdgBaseDir("XDG_CONFIG_HOME", ".config"), "pathuni")
+}
+
+// defaultConfigPath is the config path reported when discovery finds nothing.
+func defaultConfigPath() string {
+	return filepath.Join(userConfigDir(), "my_paths.yaml")
+}
+
+// candidatesInDir considers the accepted config names in dir, loading the
+// first one that exists.
+func candidatesInDir(dir, layer string) []configCandidate {
+	var out []configCandidate
+	loaded := ""
+	for _, name := range configFileNames {
+		c := configCandidate{Path: filepath.Join(dir, name), Layer: layer}
+		switch {
+		case !fileExists(c.Path) && filepath.Ext(name) != ".yaml":
+			continue // only list the other encodings when present
+		case !fileExists(c.Path):
+			c.Note = "not found"
+		case loaded != "":
+			c.Note = fmt.Sprintf("shadowed by %s", loaded)
+		default:
+			c.Loaded = true
+			loaded = name
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// projectConfigIn returns the project config file in dir, if any.
+func projectConfigIn(dir string) (string, bool) {
+	for _, name := range projectConfigNames {
+		if candidate := filepath.Join(dir, name); fileExists(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// findProjectConfig walks up from dir looking for a project config file.
+func findProjectConfig(dir string) (string, bool) {
+	for {
+		if candidate, ok := projectConfigIn(dir); ok {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// discoverConfigFiles returns every config file considered, in layering order
+// (system, user, project). System directories are layered from least to most
+// important so the first entry of $XDG_CONFIG_DIRS wins. The project file is
+// not loaded while the directory hook is installed, which manages it instead.
+func discoverConfigFiles() []configCandidate {
+	var candidates []configCandidate
+	system := xdgConfigDirs()
+	for i := len(system) - 1; i >= 0; i-- {
+		candidates = append(candidates, candidatesInDir(filepath.Join(system[i], "pathuni"), "system")...)
+	}
+	candidates = append(candidates, candidatesInDir(userConfigDir(), "user")...)
+
+	if cwd, err := os.Getwd(); err == nil {
+		if path, ok := findProjectConfig(cwd); ok {
+			c := configCandidate{Path: path, Layer: "project"}
+			switch {
+			case os.Getenv(hookShellVar) != "":
+				c.Note = "left to the directory hook"
+			case isAllowed(path):
+				c.Loaded = true
+			default:
+				c.Note = "not allowed, run 'pathuni allow'"
+			}
+			candidates = append(candidates, c)
+		} else {
+			candidates = append(candidates, configCandidate{
+				Path:  filepath.Join(cwd, projectConfigName),
+				Layer: "project",
+				Note:  "not found in current directory or parents",
+			})
+		}
+	}
+	return candidates
+}
+
+// discoveredConfigPath joins the loaded candidates into a config path list,
+// falling back to the default user config path when none was found.
+func discoveredConfigPath(candidates []configCandidate) string {
+	var paths []string
+	for _, c := range candidates {
+		if c.Loaded {
+			paths = append(paths, c.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return defaultConfigPath()
+	}
+	return strings.Join(paths, string(os.PathListSeparator))
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// configExists reports whether any file of a config path list exists.
+func configExists(configPath string) bool {
+	for _, path := range filepath.SplitList(configPath) {
+		if fileExists(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// readConfigFiles reads and merges every file of a config path list, without
+// validating the result. Unknown keys are rejected unless --lenient is set.
+// Parse errors name the offending file when there are several.
+func readConfigFiles(configPath string) (*Config, error) {
+	paths := filepath.SplitList(configPath)
+	var merged Config
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %v", err)
+		}
+		var cfg Config
+		doc, err := parseConfigDocument(path, data)
+		if err == nil && len(doc.Content) > 0 {
+			err = doc.Decode(&cfg)
+		}
+		if err != nil {
+			if len(paths) > 1 {
+				return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+			}
+			return nil, fmt.Errorf("error parsing config file: %v", err)
+		}
+		if !lenient {
+			if err := checkConfigNode(doc, path); err != nil {
+				return nil, err
+			}
+		}
+		mergeConfig(&merged, cfg)
+	}
+	return &merged, nil
+}
+
+// mergeConfig layers over on top of base. Path entries of the more specific
+// layer come first so they take precedence in the PATH; providers and remove
+// patterns are combined, and platform tags only reach the entries of their own
+// layer; defaults, profiles, vars and the shell settings of the more specific
+// layer win.
+func mergeConfig(base *Config, over Config) {
+	mergePlatformConfig(&base.All, over.All)
+	mergePlatformConfig(&base.Linux, over.Linux)
+	mergePlatformConfig(&base.MacOS, over.MacOS)
+
+	base.Defaults = overlayProfile(base.Defaults, over.Defaults)
+	for name, p := range over.Profiles {
+		if base.Profiles == nil {
+			base.Profiles = make(map[string]Profile)
+		}
+		base.Profiles[name] = p
+	}
+	for name, v := range over.Vars {
+		if base.Vars == nil {
+			base.Vars = make(map[string]string)
+		}
+		base.Vars[name] = v
+	}
+}
+
+// mergePlatformConfig layers a platform section. The combined tags would
+// leak onto the untagged entries of every other layer, so each layer's
+// untagged entries get that layer's own platform tags first.
+func mergePlatformConfig(base *PlatformConfig, over PlatformConfig) {
+	if len(over.Tags) > 0 {
+		over.Paths = withPlatformTags(over.Paths, over.Tags)
+		for _, sc := range sectionShellConfigs(over) {
+			if sc != nil && sc.Tags == nil {
+				sc.Tags = append([]string{}, over.Tags...)
+			}
+		}
+	}
+	base.Tags = appendUnique(base.Tags, over.Tags)
+	base.Paths = append(append([]interface{}{}, over.Paths...), base.Paths...)
+	base.Providers = appendUnique(base.Providers, over.Providers)
+	base.Remove = appendUnique(base.Remove, over.Remove)
+	if over.PowerShell != nil {
+		base.PowerShell = over.PowerShell
+	}
+	for name, sc := range over.Shells {
+		if base.Shells == nil {
+			base.Shells = make(map[string]*ShellConfig)
+		}
+		base.Shells[name] = sc
+	}
+	if over.SystemSource != "" {
+		base.SystemSource = over.SystemSource
+	}
+}
+
+// withPlatformTags returns paths with tags written into every entry that has
+// no tags field, as they would have inherited them.
+func withPlatformTags(paths []interface{}, tags []string) []interface{} {
+	tagList := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		tagList[i] = tag
+	}
+	out := make([]interface{}, len(paths))
+	for i, item := range paths {
+		switch v := item.(type) {
+		case string:
+			out[i] = map[string]interface{}{"path": v, "tags": tagList}
+		case map[string]interface{}:
+			if _, hasTags := v["tags"]; hasTags {
+				out[i] = v
+				continue
+			}
+			entry := make(map[string]interface{}, len(v)+1)
+			for key, value := range v {
+				entry[key] = value
+			}
+			entry["tags"] = tagList
+			out[i] = entry
+		default:
+			out[i] = item
+		}
+	}
+	return out
+}
+
+// sectionShellConfigs returns the shell settings of a platform section,
+// including the legacy powershell key.
+func sectionShellConfigs(pc PlatformConfig) []*ShellConfig {
+	configs := []*ShellConfig{pc.PowerShell}
+	for _, sc := range pc.Shells {
+		configs = append(configs, sc)
+	}
+	return configs
+}
+
+// appendUnique appends the values of extra not already in base. Duplicates
+// within extra itself are kept so validation still reports them.
+func appendUnique(base, extra []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, v := range base {
+		seen[v] = true
+	}
+	for _, v := range extra {
+		if !seen[v] {
+			base = append(base, v)
+		}
+	}
+	return base
+}
+
+// renderConfigCandidate renders a considered config file for dry-run output.
+func renderConfigCandidate(c configCandidate) string {
+	if c.Loaded {
+		return fmt.Sprintf("  [+] %s (%s)", c.Path, c.Layer)
+	}
+	return fmt.Sprintf("  [ ] %s (%s, %s)", c.Path, c.Layer, c.Note)
+}
+
+// configCandidatesFor describes the files behind configPath for dry-run: the
+// discovery results when it came from discovery, otherwise each listed file.
+func configCandidatesFor(configPath string) []configCandidate {
+	if config == "" {
+		if candidates := discoverConfigFiles(); discoveredConfigPath(candidates) == configPath {
+			return candidates
+		}
+	}
+	var out []configCandidate
+	for _, path := range filepath.SplitList(configPath) {
+		c := configCandidate{Path: path, Layer: "explicit", Loaded: fileExists(path)}
+		if !c.Loaded {
+			c.Note = "not found"
+		}
+		out = append(out, c)
+	}
+	return out
+}
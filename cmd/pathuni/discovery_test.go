@@ -0,0 +1,231 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// setupDiscoveryTree lays out a system, user and allowed project config and
+// points the XDG variables and the working directory at them.
+func setupDiscoveryTree(t *testing.T) (system, user, project string) {
+	t.Helper()
+	root := t.TempDir()
+	system = filepath.Join(root, "etc", "xdg", "pathuni", "config.yaml")
+	user = filepath.Join(root, "home", ".config", "pathuni", "my_paths.yaml")
+	project = filepath.Join(root, "repo", projectConfigName)
+
+	writeFile(t, system, "all:\n  paths:\n    - /tmp/pathuni/usr/local/bin\n  remove:\n    - /tmp/pathuni/usr/games\ndefaults:\n  prune: none\n  scope: full\n")
+	writeFile(t, user, "all:\n  paths:\n    - /tmp/pathuni/opt/tools\ndefaults:\n  prune: all\n")
+	writeFile(t, project, "all:\n  paths:\n    - /tmp/pathuni/opt/dev/bin\n  remove:\n    - /tmp/pathuni/snap/*\n")
+
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(root, "etc", "xdg"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "home", ".config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	hash, err := fileHash(project)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if err := writeAllowList(map[string]string{project: hash}); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	nested := filepath.Join(root, "repo", "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	t.Chdir(nested)
+	return system, user, project
+}
+
+func TestDiscovery_FindsAndLayersFiles(t *testing.T) {
+	system, user, project := setupDiscoveryTree(t)
+
+	candidates := discoverConfigFiles()
+	var loaded []string
+	for _, c := range candidates {
+		if c.Loaded {
+			loaded = append(loaded, c.Layer+":"+c.Path)
+		}
+	}
+	want := []string{"system:" + system, "user:" + user, "project:" + project}
+	if !reflect.DeepEqual(loaded, want) {
+		t.Errorf("loaded files\n got: %v\nwant: %v", loaded, want)
+	}
+
+	config = ""
+	configPath := getConfigPath()
+	if configPath != strings.Join([]string{system, user, project}, string(os.PathListSeparator)) {
+		t.Errorf("unexpected discovered config path: %s", configPath)
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	wantPaths := []interface{}{"/tmp/pathuni/opt/dev/bin", "/tmp/pathuni/opt/tools", "/tmp/pathuni/usr/local/bin"}
+	if !reflect.DeepEqual(cfg.All.Paths, wantPaths) {
+		t.Errorf("more specific layers should come first, got %v", cfg.All.Paths)
+	}
+	if !reflect.DeepEqual(cfg.All.Remove, []string{"/tmp/pathuni/usr/games", "/tmp/pathuni/snap/*"}) {
+		t.Errorf("remove patterns should be combined, got %v", cfg.All.Remove)
+	}
+	if cfg.Defaults.Prune != "all" || cfg.Defaults.Scope != "full" {
+		t.Errorf("user defaults should override system defaults, got %+v", cfg.Defaults)
+	}
+}
+
+func TestDiscovery_ProjectNeedsAllow(t *testing.T) {
+	_, _, project := setupDiscoveryTree(t)
+	if err := writeAllowList(map[string]string{}); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+
+	projectCandidate := func() configCandidate {
+		candidates := discoverConfigFiles()
+		return candidates[len(candidates)-1]
+	}
+	last := projectCandidate()
+	if last.Path != project || last.Loaded || !strings.Contains(last.Note, "pathuni allow") {
+		t.Errorf("untrusted project file must not be loaded, got %+v", last)
+	}
+
+	captureOutput(func() {
+		if err := runAllow(nil); err != nil {
+			t.Errorf("allow: %v", err)
+		}
+	})
+	if !projectCandidate().Loaded {
+		t.Errorf("allowed project file should be loaded")
+	}
+
+	// Editing the file revokes trust
+	writeFile(t, project, "all:\n  paths:\n    - /tmp/pathuni/evil\n")
+	if projectCandidate().Loaded {
+		t.Errorf("edited project file must not be loaded")
+	}
+}
+
+func TestDiscovery_ProjectLeftToHook(t *testing.T) {
+	_, _, project := setupDiscoveryTree(t)
+	t.Setenv(hookShellVar, "bash")
+
+	candidates := discoverConfigFiles()
+	last := candidates[len(candidates)-1]
+	if last.Path != project || last.Loaded || !strings.Contains(last.Note, "directory hook") {
+		t.Errorf("project file should be left to the hook, got %+v", last)
+	}
+}
+
+func TestDiscovery_PrefersConfigYamlAndFallsBack(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "config")
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(t.TempDir(), "none"))
+	t.Chdir(t.TempDir())
+
+	// Nothing found: the default user path is reported
+	if got := discoveredConfigPath(discoverConfigFiles()); got != filepath.Join(home, "pathuni", "my_paths.yaml") {
+		t.Errorf("unexpected fallback path: %s", got)
+	}
+
+	writeFile(t, filepath.Join(home, "pathuni", "my_paths.yaml"), "all:\n  paths: []\n")
+	writeFile(t, filepath.Join(home, "pathuni", "config.yaml"), "all:\n  paths: []\n")
+	var notes []string
+	for _, c := range discoverConfigFiles() {
+		if c.Layer == "user" {
+			notes = append(notes, renderConfigCandidate(c))
+		}
+	}
+	want := []string{
+		"  [+] " + filepath.Join(home, "pathuni", "config.yaml") + " (user)",
+		"  [ ] " + filepath.Join(home, "pathuni", "my_paths.yaml") + " (user, shadowed by config.yaml)",
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("user candidates\n got: %v\nwant: %v", notes, want)
+	}
+}
+
+func TestDiscovery_ExplicitListAndDryRun(t *testing.T) {
+	setupTestFilesystem(t)
+	system, user, project := setupDiscoveryTree(t)
+
+	prune, scope, tagsInclude, tagsExclude = "pathuni", "pathuni", "", ""
+	config = ""
+	dry := captureDryRunOutput(func() { _ = PrintDryRunReport(getConfigPath(), "Linux", "bash", false, false, "pathuni") })
+	for _, want := range []string{
+		"Config files:\n",
+		"  [+] " + system + " (system)\n",
+		"  [ ] " + filepath.Join(filepath.Dir(system), "my_paths.yaml") + " (system, not found)\n",
+		"  [+] " + user + " (user)\n",
+		"  [+] " + project + " (project)\n",
+		"  [+] /tmp/pathuni/opt/dev/bin\n",
+	} {
+		if !strings.Contains(dry, want) {
+			t.Errorf("dry-run missing %q, got:\n%s", want, dry)
+		}
+	}
+
+	// An explicit list layers the given files only, in order
+	config = strings.Join([]string{user, filepath.Join(t.TempDir(), "missing.yaml")}, string(os.PathListSeparator))
+	defer func() { config = "" }()
+	if _, err := loadConfig(getConfigPath()); err == nil {
+		t.Error("expected error for a missing file in an explicit list")
+	}
+	candidates := configCandidatesFor(getConfigPath())
+	if len(candidates) != 2 || !candidates[0].Loaded || candidates[1].Loaded || candidates[1].Layer != "explicit" {
+		t.Errorf("unexpected explicit candidates: %+v", candidates)
+	}
+
+	// Single explicit files keep the original header
+	dry = captureDryRunOutput(func() { _ = PrintDryRunReport(user, "Linux", "bash", false, false, "pathuni") })
+	if strings.Contains(dry, "Config files:") {
+		t.Errorf("single config file should not list candidates, got:\n%s", dry)
+	}
+}
+
+func TestDiscovery_LayerTagsStayWithTheirFile(t *testing.T) {
+	withRootFS(t, fstest.MapFS{
+		"home/me/bin":  {Mode: fs.ModeDir | 0755},
+		"corp/bin":     {Mode: fs.ModeDir | 0755},
+		"corp/sdk/bin": {Mode: fs.ModeDir | 0755},
+	})
+	dir := t.TempDir()
+	user := filepath.Join(dir, "user.yaml")
+	project := filepath.Join(dir, "project.yaml")
+	writeFile(t, user, "all:\n  tags: [personal]\n  paths:\n    - /home/me/bin\n  shells:\n    bash:\n      paths:\n        - /corp/sdk/bin\n")
+	writeFile(t, project, "all:\n  tags: [corp]\n  paths:\n    - /corp/bin\n")
+	configPath := user + string(os.PathListSeparator) + project
+
+	for _, tt := range []struct {
+		include, exclude, want string
+	}{
+		{"", "corp", "/home/me/bin:/corp/sdk/bin"},
+		{"", "personal", "/corp/bin"},
+		{"corp", "", "/corp/bin"},
+	} {
+		filter, err := parseTagFlags(tt.include, tt.exclude)
+		if err != nil {
+			t.Fatalf("parseTagFlags: %v", err)
+		}
+		statuses, _, err := EvaluateConfigDetailed(configPath, "Linux", "bash", filter)
+		if err != nil {
+			t.Fatalf("EvaluateConfigDetailed: %v", err)
+		}
+		if got := strings.Join(includedPaths(statuses), ":"); got != tt.want {
+			t.Errorf("-t %q -x %q: included %s, want %s", tt.include, tt.exclude, got, tt.want)
+		}
+	}
+}
@@ -21,19 +21,15 @@ func captureDry(f func()) string {
 }
 
 // Test classification of macOS system paths for PowerShell in dry-run using
-// the mocked system_paths testdata via the PATHUNI_TEST_SYSTEM_PATHS_ROOT seam.
+// the mocked system_paths testdata as the target filesystem.
 func TestDryRun_PowerShell_SystemPaths_Classification(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
-    // Use testdata/system_paths as the provider for getSystemPaths
-    t.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+    // Use testdata/system_paths as the target filesystem for getSystemPaths
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
 
     // Minimal config; platform macOS, powershell include system paths
-    cfgPath := filepath.Join("/tmp", "pathuni", "home", "Pratt", ".config", "pathuni", "psys-classify.yaml")
-    if err := os.MkdirAll(filepath.Dir(cfgPath), 0755); err != nil {
-        t.Fatalf("mkdir cfg: %v", err)
-    }
+    cfgPath := filepath.Join(t.TempDir(), "psys-classify.yaml")
 
     // a) as=system (default) → expect [.] markers for mock system paths in full
     contentSystem := "macos:\n  powershell:\n    include_system_paths: true\n"
@@ -68,14 +64,10 @@ func TestDryRun_PowerShell_SystemPaths_Classification(t *testing.T) {
 // Verify that in system scope, prune=system shows not-found entries with [?]
 func TestDryRun_PowerShell_SystemScope_PruneSystem_NotFound(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
-    t.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
 
-    cfgPath := filepath.Join("/tmp", "pathuni", "home", "Pratt", ".config", "pathuni", "psys-system-prune.yaml")
-    if err := os.MkdirAll(filepath.Dir(cfgPath), 0755); err != nil {
-        t.Fatalf("mkdir cfg: %v", err)
-    }
+    cfgPath := filepath.Join(t.TempDir(), "psys-system-prune.yaml")
     content := "macos:\n  powershell:\n    include_system_paths: true\n"
     if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
         t.Fatalf("write cfg: %v", err)
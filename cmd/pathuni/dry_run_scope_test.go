@@ -22,7 +22,6 @@ func captureDryRunOutput(f func()) string {
 
 func TestDryRun_ScopeSystemAndFull(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
     // Reset globals to safe defaults for this test
     prune = "pathuni"
@@ -35,7 +34,7 @@ func TestDryRun_ScopeSystemAndFull(t *testing.T) {
 
     // system scope
     out := captureDryRunOutput(func() { _ = PrintDryRunReport(config, "macOS", "bash", false, false, "system") })
-    if !strings.Contains(out, "Flags : scope=system") { t.Fatalf("missing flags header in system output: %s", out) }
+    if !strings.Contains(out, "Flags  : scope=system") { t.Fatalf("missing flags header in system output: %s", out) }
     if !strings.Contains(out, "[.] /tmp/pathuni/usr/bin") || !strings.Contains(out, "[.] /tmp/pathuni/bin") {
         t.Fatalf("system entries not listed with [.] marker: %s", out)
     }
@@ -44,7 +43,7 @@ func TestDryRun_ScopeSystemAndFull(t *testing.T) {
     // full scope
     prune = "pathuni" // default behavior shows only pathuni reasons
     out = captureDryRunOutput(func() { _ = PrintDryRunReport(config, "macOS", "bash", false, false, "full") })
-    if !strings.Contains(out, "Flags : scope=full") { t.Fatalf("missing flags header in full output: %s", out) }
+    if !strings.Contains(out, "Flags  : scope=full") { t.Fatalf("missing flags header in full output: %s", out) }
     if !strings.Contains(out, "[+] /tmp/pathuni/usr/local/bin") { t.Fatalf("expected pathuni entry not found with [+]: %s", out) }
     if !strings.Contains(out, "[.] /tmp/pathuni/bin") { t.Fatalf("system entry not found with [.]: %s", out) }
     if !strings.Contains(out, "Paths included in total") || !strings.Contains(out, "Pathuni path") || !strings.Contains(out, "System path") {
@@ -54,10 +53,9 @@ func TestDryRun_ScopeSystemAndFull(t *testing.T) {
 
 func TestDryRun_PruneVariants(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
     // Prepare a temp config with one existing and one missing path
-    tmpCfg, err := os.CreateTemp("/tmp/pathuni/home/Pratt/.config/pathuni", "cfg-*.yaml")
+    tmpCfg, err := os.CreateTemp(t.TempDir(), "cfg-*.yaml")
     if err != nil { t.Fatalf("temp cfg: %v", err) }
     defer os.Remove(tmpCfg.Name())
     cfgContent := "all:\n  paths:\n    - \"/tmp/pathuni/usr/local/bin\"\n    - \"/tmp/pathuni/does-not-exist\"\n"
@@ -7,7 +7,6 @@ import (
 
 func TestDryRun_DetailedOutput(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	testConfigPath := filepath.Join("testdata", "dry_run_comprehensive.yaml")
 
@@ -147,7 +146,6 @@ func TestDryRun_DetailedOutput(t *testing.T) {
 
 func TestDryRun_BackwardsCompatibility(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	// Test that the old EvaluateConfig still works the same way
 	testConfigPath := filepath.Join("testdata", "dry_run_comprehensive.yaml")
@@ -188,7 +186,6 @@ func TestDryRun_BackwardsCompatibility(t *testing.T) {
 // TestDryRunV2_SkipReasons tests the new tree-structured output with skip reasons
 func TestDryRunV2_SkipReasons(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	testConfigPath := filepath.Join("testdata", "dry_run_tag_filtering.yaml")
 	
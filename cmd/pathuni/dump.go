@@ -22,25 +22,14 @@ func runDump() {
         fmt.Fprintf(os.Stderr, "Error: Invalid prune option '%s'. Use 'none', 'pathuni', 'system', or 'all'\n", prune)
         os.Exit(1)
     }
+    if !isValidDedupe(dedupe) {
+        fmt.Fprintf(os.Stderr, "Error: Invalid dedupe option '%s'. Use 'exact', 'clean', or 'realpath'\n", dedupe)
+        os.Exit(1)
+    }
 
-    var paths []string
-    var err error
-    configPath := getConfigPath()
     osName, _ := getOSName()
     shellName, _ := getShellName()
-
-    switch scope {
-    case "system":
-        paths, err = resolveSystemPathsContext(configPath, osName, shellName)
-        if err == nil && (prune == "system" || prune == "all") {
-            paths = filterExisting(paths)
-        }
-    case "pathuni":
-        paths, err = resolvePathuniPaths()
-    case "full":
-        paths, err = getAllPathsWithTagFiltering()
-    }
-
+    paths, statuses, err := resolveInitPaths(osName, shellName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -53,6 +42,8 @@ func runDump() {
 	}
 
 	fmt.Print(output)
+
+	reportMissingRequired(missingRequired(statuses))
 }
 
 func isValidFormat(format string) bool {
@@ -80,46 +71,6 @@ func getCurrentPath() ([]string, error) {
 	return strings.Split(pathEnv, ":"), nil
 }
 
-func getAllPathsWithTagFiltering() ([]string, error) {
-    // Resolve both sources with internal dedupe
-    configPath := getConfigPath()
-    osName, _ := getOSName()
-    shellName, _ := getShellName()
-    systemPaths, err := resolveSystemPathsContext(configPath, osName, shellName)
-    if err != nil {
-        return nil, err
-    }
-
-    pathuniPaths, err := resolvePathuniPaths()
-    if err != nil {
-        return nil, err
-    }
-
-    // Apply prune to system side if requested
-    if prune == "system" || prune == "all" {
-        systemPaths = filterExisting(systemPaths)
-    }
-
-    // Merge with pathuni-first precedence to align with init
-    return mergeFull(pathuniPaths, systemPaths, true), nil
-}
-
-// This function is no longer being used. Do we plan to use itin the future? Shall we remove it?
-func getPathUniPaths() ([]string, error) {
-	configPath := getConfigPath()
-	osName, _ := getOSName()
-	shellName, _ := getShellName()
-	
-	// Parse tag filters
-	tagFilter, err := parseTagFlags(tagsInclude, tagsExclude)
-	if err != nil {
-		return nil, err
-	}
-	
-	paths, _, err := collectValidPaths(configPath, osName, shellName, tagFilter)
-	return paths, err
-}
-
 func formatPaths(paths []string, format string) (string, error) {
 	switch format {
 	case "plain":
@@ -5,11 +5,11 @@ import (
     "testing"
 )
 
-// Verifies that getAllPathsWithTagFiltering returns a pathuni-first merge
-// (pathuni entries precede system entries) and contains no duplicates.
+// Verifies that the evaluation pipeline behind init and dump returns a
+// pathuni-first merge (pathuni entries precede system entries) and contains
+// no duplicates.
 func TestDump_ScopeFull_PathuniFirst(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
     // Point config to a known test file with macOS-like entries
     config = filepath.Join("testdata", "valid_config.yaml")
@@ -20,15 +20,16 @@ func TestDump_ScopeFull_PathuniFirst(t *testing.T) {
     t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/bin")
 
     // Collect individual sources
-    system, err := resolveSystemPaths()
+    system, err := resolveSystemPathsContext(config, "macOS", "bash")
     if err != nil {
-        t.Fatalf("resolveSystemPaths error: %v", err)
+        t.Fatalf("resolveSystemPathsContext error: %v", err)
     }
 
-    pathuni, err := resolvePathuniPaths()
+    statuses, _, err := evaluateConfigIn(config, "", "macOS", "bash", TagFilter{})
     if err != nil {
-        t.Fatalf("resolvePathuniPaths error: %v", err)
+        t.Fatalf("evaluateConfigIn error: %v", err)
     }
+    pathuni := includedPaths(statuses)
 
     // Sanity: ensure we have at least one exclusive from each side
     sysSet := make(map[string]bool)
@@ -37,9 +38,10 @@ func TestDump_ScopeFull_PathuniFirst(t *testing.T) {
     for _, p := range pathuni { puSet[p] = true }
 
     // Compute merged list
-    merged, err := getAllPathsWithTagFiltering()
-    if err != nil {
-        t.Fatalf("getAllPathsWithTagFiltering error: %v", err)
+    entries, _ := mergePlacedDetailed(placedFromStatuses(statuses, true), system)
+    var merged []string
+    for _, e := range entries {
+        merged = append(merged, e.Path)
     }
 
     // Check no duplicates
@@ -21,7 +21,6 @@ func captureDumpOutput(f func()) string {
 
 func TestDump_Prune_SystemAndPathuni(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
     // Ensure deferEnv is off for dump tests
     deferEnv = false
@@ -46,7 +45,7 @@ func TestDump_Prune_SystemAndPathuni(t *testing.T) {
     }
 
     // Now verify pathuni behavior with temp config
-    tmpCfg, err := os.CreateTemp("/tmp/pathuni/home/Pratt/.config/pathuni", "cfg-*.yaml")
+    tmpCfg, err := os.CreateTemp(t.TempDir(), "cfg-*.yaml")
     if err != nil { t.Fatalf("temp cfg: %v", err) }
     defer os.Remove(tmpCfg.Name())
     cfgContent := "all:\n  paths:\n    - \"/tmp/pathuni/usr/local/bin\"\n    - \"/tmp/pathuni/does-not-exist\"\n"
@@ -0,0 +1,226 @@
+package main
+
+// Path expansion. os.ExpandEnv turns an unset variable into an empty string,
+// so "$SDK_HOME/bin" silently becomes "/bin". expandPath instead reports
+// unset variables, supports shell-style defaults and errors, tilde
+// expansion, and the variables defined under `vars:` in the config.
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// varNameRegex matches names usable as $name or ${name}.
+var varNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// unsetVarError reports a variable without a value and without a default.
+type unsetVarError struct {
+	Name    string
+	Message string // From ${NAME:?message}
+}
+
+func (e *unsetVarError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Name, e.Message)
+	}
+	return fmt.Sprintf("unset variable $%s", e.Name)
+}
+
+// expander expands one string against the config variables and the
+// environment, tracking the variables being expanded to catch cycles.
+type expander struct {
+	vars  map[string]string
+	stack []string
+}
+
+// expandPath expands a leading ~ or ~user, and $NAME, ${NAME},
+// ${NAME:-default} and ${NAME:?message} anywhere in s. Names are looked up in
+// vars first, then in the environment; a variable that is unset or empty
+// and has no default yields an *unsetVarError.
+func expandPath(s string, vars map[string]string) (string, error) {
+	e := &expander{vars: vars}
+	return e.expand(expandTildePrefix(s))
+}
+
+// expandTildePrefix expands "~" and "~user" at the start of s. Unknown users
+// are left as written, like the shell does.
+func expandTildePrefix(s string) string {
+	if !strings.HasPrefix(s, "~") {
+		return s
+	}
+	name, rest := s[1:], ""
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	if name == "" {
+		if home := homeDir(); home != "" {
+			return home + rest
+		}
+		return s
+	}
+	if u, err := user.Lookup(name); err == nil && u.HomeDir != "" {
+		return u.HomeDir + rest
+	}
+	return s
+}
+
+func (e *expander) expand(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if s[i+1] == '{' {
+			end := matchingBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			value, err := e.expandBraced(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+			continue
+		}
+
+		j := i + 1
+		for j < len(s) && (s[j] == '_' || isAlnum(s[j])) {
+			j++
+		}
+		if j == i+1 || (s[i+1] >= '0' && s[i+1] <= '9') {
+			b.WriteByte('$') // not a variable reference
+			continue
+		}
+		value, err := e.lookup(s[i+1 : j])
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return "", &unsetVarError{Name: s[i+1 : j]}
+		}
+		b.WriteString(value)
+		i = j - 1
+	}
+	return b.String(), nil
+}
+
+// expandBraced expands the inside of ${...}.
+func (e *expander) expandBraced(inner string) (string, error) {
+	name, op, word := inner, "", ""
+	if i := strings.Index(inner, ":"); i >= 0 {
+		name, op, word = inner[:i], inner[i:min(i+2, len(inner))], inner[min(i+2, len(inner)):]
+	}
+	if !varNameRegex.MatchString(name) || (op != "" && op != ":-" && op != ":?") {
+		return "", fmt.Errorf("invalid variable reference ${%s}", inner)
+	}
+
+	value, err := e.lookup(name)
+	if err != nil {
+		return "", err
+	}
+	if value != "" {
+		return value, nil
+	}
+	switch op {
+	case ":-":
+		return e.expand(expandTildePrefix(word))
+	case ":?":
+		return "", &unsetVarError{Name: name, Message: word}
+	}
+	return "", &unsetVarError{Name: name}
+}
+
+// lookup returns the value of a config variable (expanded) or environment
+// variable, "" when neither is set.
+func (e *expander) lookup(name string) (string, error) {
+	raw, ok := e.vars[name]
+	if !ok {
+		return os.Getenv(name), nil
+	}
+	for i, n := range e.stack {
+		if n == name {
+			return "", fmt.Errorf("variable cycle: %s -> %s", strings.Join(e.stack[i:], " -> "), name)
+		}
+	}
+	e.stack = append(e.stack, name)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+	return e.expand(expandTildePrefix(raw))
+}
+
+// matchingBrace returns the index of the "}" closing the "{" at open.
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// validateVars checks variable names and reports cycles between variables.
+// Variables referring to unset environment variables are fine here: the
+// paths using them are skipped at evaluation time.
+func validateVars(vars map[string]string) error {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !varNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid variable name '%s' in vars: must start with a letter or underscore and contain only letters, numbers, and underscores", name)
+		}
+		if _, err := expandPath("${"+name+"}", vars); err != nil {
+			if _, unset := err.(*unsetVarError); !unset {
+				return fmt.Errorf("invalid vars.%s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expansionWarned records the skip warnings already printed.
+var expansionWarned = make(map[string]bool)
+
+// expandOrSkip expands s like expandPath for config values that are not path
+// entries, such as remove patterns and position anchors. When expansion fails
+// the caller skips the value; the reason is printed to stderr once, as a
+// command may expand the same config more than once.
+func expandOrSkip(what, s string, vars map[string]string) (string, bool) {
+	expanded, err := expandPath(s, vars)
+	if err == nil {
+		return expanded, true
+	}
+	msg := fmt.Sprintf("pathuni: %s %q skipped: %v", what, s, err)
+	if !expansionWarned[msg] {
+		expansionWarned[msg] = true
+		fmt.Fprintln(os.Stderr, msg)
+	}
+	return "", false
+}
+
+// expansionSkipReason turns an expansion error into a skip reason.
+func expansionSkipReason(err error) *SkipReason {
+	if _, ok := err.(*unsetVarError); ok {
+		return &SkipReason{Type: "unset_var", Detail: err.Error()}
+	}
+	return &SkipReason{Type: "invalid_var", Detail: err.Error()}
+}
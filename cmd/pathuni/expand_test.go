@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpand_Path(t *testing.T) {
+	t.Setenv("HOME", "/home/tester")
+	t.Setenv("PATHUNI_EXPAND_SET", "/opt/sdk")
+	t.Setenv("PATHUNI_EXPAND_EMPTY", "")
+	t.Setenv("brew_prefix", "/from/env")
+	vars := map[string]string{
+		"brew_prefix": "/opt/homebrew",
+		"brew_bin":    "${brew_prefix}/bin",
+		"tools":       "~/tools",
+	}
+
+	root := "~root"
+	if u, err := user.Lookup("root"); err == nil {
+		root = u.HomeDir
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"~", "/home/tester"},
+		{"~/.local/bin", "/home/tester/.local/bin"},
+		{"~root/bin", root + "/bin"},
+		{"~pathuni_no_such_user/bin", "~pathuni_no_such_user/bin"},
+		{"/usr/~/bin", "/usr/~/bin"},
+		{"$PATHUNI_EXPAND_SET/bin", "/opt/sdk/bin"},
+		{"${PATHUNI_EXPAND_SET}bin", "/opt/sdkbin"},
+		{"${PATHUNI_EXPAND_UNSET:-/usr/local}/bin", "/usr/local/bin"},
+		{"${PATHUNI_EXPAND_EMPTY:-~/sdk}/bin", "/home/tester/sdk/bin"},
+		{"${PATHUNI_EXPAND_UNSET:-${PATHUNI_EXPAND_SET}}/bin", "/opt/sdk/bin"},
+		{"${brew_prefix}/sbin", "/opt/homebrew/sbin"},
+		{"$brew_bin", "/opt/homebrew/bin"},
+		{"$tools/bin", "/home/tester/tools/bin"},
+		{"/opt/$5/a$", "/opt/$5/a$"},
+	}
+	for _, tt := range tests {
+		got, err := expandPath(tt.in, vars)
+		if err != nil || got != tt.want {
+			t.Errorf("expandPath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpand_Errors(t *testing.T) {
+	t.Setenv("PATHUNI_EXPAND_EMPTY", "")
+	vars := map[string]string{"a": "$b/x", "b": "${a}"}
+
+	tests := []struct {
+		in, want string
+		unset    bool
+	}{
+		{"$PATHUNI_EXPAND_UNSET/bin", "unset variable $PATHUNI_EXPAND_UNSET", true},
+		{"${PATHUNI_EXPAND_EMPTY}/bin", "unset variable $PATHUNI_EXPAND_EMPTY", true},
+		{"${PATHUNI_EXPAND_UNSET:?install the SDK first}", "PATHUNI_EXPAND_UNSET: install the SDK first", true},
+		{"$a", "variable cycle: a -> b -> a", false},
+		{"${PATHUNI_EXPAND_UNSET:=x}", "invalid variable reference ${PATHUNI_EXPAND_UNSET:=x}", false},
+		{"${PATHUNI_EXPAND_UNSET", `unterminated ${ in "${PATHUNI_EXPAND_UNSET"`, false},
+	}
+	for _, tt := range tests {
+		_, err := expandPath(tt.in, vars)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("expandPath(%q) error = %v, want %q", tt.in, err, tt.want)
+			continue
+		}
+		if _, unset := err.(*unsetVarError); unset != tt.unset {
+			t.Errorf("expandPath(%q): unset error = %v, want %v", tt.in, unset, tt.unset)
+		}
+	}
+}
+
+func TestExpand_ConfigVars(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "vars.yaml")
+	writeFile(t, cfgPath, `vars:
+  test_root: /tmp/pathuni
+all:
+  paths:
+    - ${test_root}/usr/local/bin
+    - $PATHUNI_EXPAND_UNSET/bin
+    - ${PATHUNI_EXPAND_UNSET:-/tmp/pathuni}/bin
+`)
+
+	result, err := EvaluateConfigWithReasons(cfgPath, "Linux", "bash", TagFilter{})
+	if err != nil {
+		t.Fatalf("EvaluateConfigWithReasons: %v", err)
+	}
+	if len(result.IncludedPaths) != 2 || result.IncludedPaths[0] != "/tmp/pathuni/usr/local/bin" || result.IncludedPaths[1] != "/tmp/pathuni/bin" {
+		t.Errorf("unexpected included paths: %v", result.IncludedPaths)
+	}
+	if len(result.SkippedPaths) != 1 {
+		t.Fatalf("expected one skipped path, got %v", result.SkippedPaths)
+	}
+	want := "  [!] $PATHUNI_EXPAND_UNSET/bin\n       └unset variable $PATHUNI_EXPAND_UNSET"
+	if got := renderSkippedPath(result.SkippedPaths[0]); got != want {
+		t.Errorf("unexpected rendering:\n got: %q\nwant: %q", got, want)
+	}
+
+	// The unset entry must not leak into the PATH as "/bin"
+	statuses, _, err := EvaluateConfigDetailed(cfgPath, "Linux", "bash", TagFilter{})
+	if err != nil {
+		t.Fatalf("EvaluateConfigDetailed: %v", err)
+	}
+	for _, p := range includedPaths(statuses) {
+		if p == "/bin" {
+			t.Errorf("unset variable produced %q", p)
+		}
+	}
+}
+
+func TestExpand_ValidateVars(t *testing.T) {
+	for _, tt := range []struct {
+		content, want string
+	}{
+		{"vars:\n  a: $b\n  b: $a\n", "invalid vars.a: variable cycle: a -> b -> a"},
+		{"vars:\n  brew-prefix: /opt/homebrew\n", "invalid variable name 'brew-prefix' in vars"},
+	} {
+		cfgPath := filepath.Join(t.TempDir(), "vars.yaml")
+		writeFile(t, cfgPath, tt.content)
+		if _, err := loadConfig(cfgPath); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+	}
+
+	cfgPath := filepath.Join(t.TempDir(), "vars.yaml")
+	writeFile(t, cfgPath, "vars:\n  sdk: $PATHUNI_EXPAND_UNSET\n")
+	if _, err := loadConfig(cfgPath); err != nil {
+		t.Errorf("vars referencing unset environment variables should load: %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+// Static artifacts. `pathuni export --target` renders the computed PATH for
+// places that never run an interactive shell: /etc/profile.d, systemd's
+// environment.d, a launchd agent and ~/.pam_environment. The profile.d and
+// environment.d files apply to every login, so they place the pathuni entries
+// around the PATH they are evaluated with, like --defer-env, unless
+// --full-path asks for the expanded PATH. CI and container targets (GitHub
+// Actions, Dockerfile, dotenv, Makefile) always render the pathuni entries
+// around the target's own PATH. With --write the artifact is installed
+// atomically after showing a diff against the file it replaces.
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportTarget       string
+	exportWrite        bool
+	exportOutput       string
+	exportPruneMissing bool
+	exportFullPath     bool
+)
+
+// launchdLabel identifies the launchd agent setting the PATH.
+const launchdLabel = "com.pathuni.path"
+
+const exportHeader = "Generated by pathuni export --target %s; changes will be overwritten."
+
+// exportSpec describes an artifact: how to render it and where --write
+// installs it by default (nil: --output is required). Render takes the
+// computed PATH; Defer takes the pathuni entries placed around the target's
+// PATH. A target with both renders the deferred form unless --full-path is
+// set. Defer-only targets live on another machine, so their entries are not
+// checked for existence unless --prune-missing is set.
+type exportSpec struct {
+	Render func(paths []string) string
+	Defer  func(plan deferPlan) string
+	File   func() string
+}
+
+var exportTargets = map[string]exportSpec{
+	"profile.d": {
+		Render: renderProfileD,
+		Defer:  renderProfileDDefer,
+		File:   func() string { return "/etc/profile.d/pathuni.sh" },
+	},
+	"environment.d": {
+		Render: renderEnvironmentD,
+		Defer:  renderEnvironmentDDefer,
+		File: func() string {
+			return filepath.Join(xdgBaseDir("XDG_CONFIG_HOME", ".config"), "environment.d", "50-pathuni.conf")
+		},
+	},
+	"launchd": {
+		Render: renderLaunchd,
+		File:   func() string { return homeFile("Library", "LaunchAgents", launchdLabel+".plist") },
+	},
+	"pam_env": {
+		Render: renderPamEnv,
+		File:   func() string { return homeFile(".pam_environment") },
+	},
+	"github":     {Defer: renderGitHubPath},
+	"dockerfile": {Defer: renderDockerfile},
+	"dotenv":     {Defer: renderDotenv},
+	"makefile":   {Defer: renderMakefile},
+}
+
+func exportTargetNames() []string {
+	names := make([]string, 0, len(exportTargets))
+	for name := range exportTargets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func homeFile(elem ...string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(append([]string{home}, elem...)...)
+}
+
+func renderProfileD(paths []string) string {
+	return "# " + fmt.Sprintf(exportHeader, "profile.d") + "\n" +
+		"export PATH=" + shQuote(strings.Join(paths, ":")) + "\n"
+}
+
+// renderProfileDDefer renders the --defer-env code for bash, which is POSIX
+// and can be sourced by any login shell.
+func renderProfileDDefer(plan deferPlan) string {
+	return "# " + fmt.Sprintf(exportHeader, "profile.d") + "\n" +
+		renderBashDefer(plan) + "\n"
+}
+
+// environmentDEscape escapes what environment.d values expand: $VAR and
+// backslash escapes.
+var environmentDEscape = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `"`, `\"`, `'`, `\'`)
+
+// renderEnvironmentD renders a systemd environment.d file.
+func renderEnvironmentD(paths []string) string {
+	return "# " + fmt.Sprintf(exportHeader, "environment.d") + "\n" +
+		"PATH=" + environmentDEscape.Replace(strings.Join(paths, ":")) + "\n"
+}
+
+// renderEnvironmentDDefer places the entries around the PATH set by earlier
+// environment.d files. Remove patterns cannot be expressed there.
+func renderEnvironmentDDefer(plan deferPlan) string {
+	return "# " + fmt.Sprintf(exportHeader, "environment.d") + "\n" +
+		"PATH=" + deferValue(plan, environmentDEscape, "${PATH}") + "\n"
+}
+
+// renderLaunchd renders a launchd agent that sets the PATH for GUI apps
+// at login.
+func renderLaunchd(paths []string) string {
+	var value bytes.Buffer
+	xml.EscapeText(&value, []byte(strings.Join(paths, ":")))
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<!-- ` + fmt.Sprintf(exportHeader, "launchd") + ` -->
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + launchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/bin/launchctl</string>
+		<string>setenv</string>
+		<string>PATH</string>
+		<string>` + value.String() + `</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+}
+
+// renderPamEnv renders a pam_env file. DEFAULT values expand ${VAR} and
+// @{ITEM}, so '$', '@', quotes and backslashes are escaped.
+func renderPamEnv(paths []string) string {
+	value := strings.NewReplacer(`\`, `\\`, `$`, `\$`, `@`, `\@`, `"`, `\"`).Replace(strings.Join(paths, ":"))
+	return "# " + fmt.Sprintf(exportHeader, "pam_env") + "\n" +
+		"PATH DEFAULT=\"" + value + "\"\n"
+}
+
+// renderGitHubPath renders lines for $GITHUB_PATH. Each line is prepended
+// to the PATH in turn, so the highest precedence entry comes last; appended
+// entries cannot be expressed and go below the prepended ones.
+func renderGitHubPath(plan deferPlan) string {
+	var b strings.Builder
+	entries := append(append([]string{}, plan.Prefix...), plan.Suffix...)
+	for i := len(entries) - 1; i >= 0; i-- {
+		b.WriteString(entries[i] + "\n")
+	}
+	return b.String()
+}
+
+// renderDockerfile renders an ENV instruction. Double-quoted values expand
+// $VAR and backslash escapes.
+func renderDockerfile(plan deferPlan) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+	return "# " + fmt.Sprintf(exportHeader, "dockerfile") + "\n" +
+		`ENV PATH="` + deferValue(plan, escape, "$PATH") + "\"\n"
+}
+
+// renderDotenv renders a dotenv PATH line using docker compose
+// interpolation: "$$" is a literal '$'.
+func renderDotenv(plan deferPlan) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`)
+	return "# " + fmt.Sprintf(exportHeader, "dotenv") + "\n" +
+		`PATH="` + deferValue(plan, escape, "${PATH}") + "\"\n"
+}
+
+// renderMakefile renders a Makefile fragment. '$' and '#' are special in
+// variable values.
+func renderMakefile(plan deferPlan) string {
+	escape := strings.NewReplacer(`$`, `$$`, `#`, `\#`)
+	return "# " + fmt.Sprintf(exportHeader, "makefile") + "\n" +
+		"export PATH := " + deferValue(plan, escape, "$(PATH)") + "\n"
+}
+
+// deferValue joins the escaped plan entries around a reference to the
+// target's PATH.
+func deferValue(plan deferPlan, escape *strings.Replacer, live string) string {
+	var parts []string
+	for _, p := range plan.Prefix {
+		parts = append(parts, escape.Replace(p))
+	}
+	parts = append(parts, live)
+	for _, p := range plan.Suffix {
+		parts = append(parts, escape.Replace(p))
+	}
+	return strings.Join(parts, ":")
+}
+
+func runExport() error {
+	spec, ok := exportTargets[exportTarget]
+	if !ok {
+		return fmt.Errorf("invalid target '%s'. Use one of: %s", exportTarget, strings.Join(exportTargetNames(), ", "))
+	}
+	osName, _ := getOSName()
+	shellName, _ := getShellName()
+	if !osIsValid(osName) {
+		return fmt.Errorf("unsupported OS '%s'. Supported OS: %s", osName, strings.Join(osNames(), ", "))
+	}
+	if !shellIsValid(shellName) {
+		return fmt.Errorf("unsupported shell '%s'. Supported shells: %s%s", shellName, strings.Join(shellNames(), ", "), configOnlyNote(shellName))
+	}
+	if exportFullPath && spec.Render == nil {
+		return fmt.Errorf("target '%s' always renders around the target's PATH; --full-path applies to %s", exportTarget, strings.Join(fullPathTargets(), ", "))
+	}
+	deferred := spec.Defer != nil && !exportFullPath
+	var paths []string
+	var statuses []PathStatus
+	var plan deferPlan
+	if deferred {
+		var err error
+		if statuses, err = evaluatePathuni(); err != nil {
+			return err
+		}
+		// Targets that also render the full PATH are installed on this machine
+		pruneMissing := exportPruneMissing
+		if spec.Render != nil {
+			pruneMissing = prunePathuni()
+			plan.Remove = configRemovePatterns(getConfigPath(), osName)
+		}
+		plan.Prefix, plan.Suffix = splitDeferPlaced(placedFromStatuses(statuses, pruneMissing))
+		paths = plan.placed()
+	} else {
+		var err error
+		if paths, statuses, err = resolveInitPaths(osName, shellName); err != nil {
+			return err
+		}
+	}
+	// Nothing is written when --strict fails
+	reportMissingRequired(missingRequired(statuses))
+	for _, p := range paths {
+		if strings.ContainsAny(p, "\n\r") {
+			return fmt.Errorf("path %q contains a line break and cannot be exported", p)
+		}
+	}
+	var content string
+	if deferred {
+		content = spec.Defer(plan)
+	} else {
+		content = spec.Render(paths)
+	}
+	if !exportWrite {
+		fmt.Print(content)
+		return nil
+	}
+
+	file := exportOutput
+	if file == "" {
+		if spec.File == nil {
+			return fmt.Errorf("target '%s' has no default location; use --output with --write", exportTarget)
+		}
+		file = spec.File()
+	}
+	old, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if string(old) == content {
+		fmt.Printf("%s is up to date\n", file)
+		return nil
+	}
+	fmt.Printf("--- %s\n+++ %s (%s)\n", file, file, exportTarget)
+	for _, line := range diffPaths(splitLines(string(old)), splitLines(content)) {
+		fmt.Println(line)
+	}
+	if err := writeFileAtomic(file, []byte(content)); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", file)
+	return nil
+}
+
+// fullPathTargets lists the targets that can render the expanded PATH.
+func fullPathTargets() []string {
+	var names []string
+	for _, name := range exportTargetNames() {
+		if spec := exportTargets[name]; spec.Render != nil && spec.Defer != nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// splitLines splits text into lines without the trailing empty one.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Render the PATH as a static file for non-interactive environments",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExport(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport_Renderers(t *testing.T) {
+	paths := []string{`/opt/it's $HOME`, `/opt/a&b @c\d`}
+	tests := map[string]string{
+		"profile.d":     `export PATH='/opt/it'\''s $HOME:/opt/a&b @c\d'`,
+		"environment.d": `PATH=/opt/it\'s \$HOME:/opt/a&b @c\\d`,
+		"pam_env":       `PATH DEFAULT="/opt/it's \$HOME:/opt/a&b \@c\\d"`,
+		"launchd":       `<string>/opt/it&#39;s $HOME:/opt/a&amp;b @c\d</string>`,
+	}
+	for target, want := range tests {
+		got := exportTargets[target].Render(paths)
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("%s: %q not found in:\n%s", target, want, got)
+		}
+		if !strings.Contains(got, "Generated by pathuni export --target "+target) {
+			t.Errorf("%s: missing header:\n%s", target, got)
+		}
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files under testdata")
+
+// TestExport_DeferTargetsGolden compares the CI targets with the files in
+// testdata/export; run with -update to regenerate them.
+func TestExport_DeferTargetsGolden(t *testing.T) {
+	plan := deferPlan{
+		Prefix: []string{"/opt/tools/bin", `/opt/it's $HOME`, `/opt/a&b #c\d "q"`},
+		Suffix: []string{"/opt/fallback/bin"},
+	}
+	for _, target := range []string{"github", "dockerfile", "dotenv", "makefile"} {
+		golden := filepath.Join("testdata", "export", target+".golden")
+		got := exportTargets[target].Defer(plan)
+		if *updateGolden {
+			writeFile(t, golden, got)
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("read golden: %v", err)
+		}
+		if got != string(want) {
+			t.Errorf("%s output differs from %s:\n got: %q\nwant: %q", target, golden, got, want)
+		}
+	}
+}
+
+func TestExport_DeferTargetsSkipPruning(t *testing.T) {
+	config = filepath.Join(t.TempDir(), "export.yaml")
+	writeFile(t, config, "all:\n  paths:\n    - /build/host/lacks/this\n")
+	osOverride, shell, prune, exportTarget = "Linux", "bash", "pathuni", "dockerfile"
+	t.Cleanup(func() {
+		config, osOverride, shell, exportTarget, exportPruneMissing, exportWrite = "", "", "", "", false, false
+	})
+
+	out := captureOutput(func() { _ = runExport() })
+	if !strings.Contains(out, `ENV PATH="/build/host/lacks/this:$PATH"`) {
+		t.Errorf("missing entries should be kept by default, got:\n%s", out)
+	}
+	exportPruneMissing = true
+	if out := captureOutput(func() { _ = runExport() }); !strings.Contains(out, `ENV PATH="$PATH"`) {
+		t.Errorf("--prune-missing should drop the entry, got:\n%s", out)
+	}
+
+	exportWrite = true
+	if err := runExport(); err == nil || !strings.Contains(err.Error(), "use --output") {
+		t.Errorf("expected --output to be required, got %v", err)
+	}
+}
+
+func TestExport_SystemTargetsDeferByDefault(t *testing.T) {
+	setupTestFilesystem(t)
+
+	config = filepath.Join(t.TempDir(), "export.yaml")
+	writeFile(t, config, "all:\n  paths:\n    - /tmp/pathuni/usr/local/bin\n    - /tmp/pathuni/missing\n  remove:\n    - /tmp/pathuni/usr/games\n")
+	osOverride, shell, scope, prune = "Linux", "bash", "full", "pathuni"
+	t.Cleanup(func() { config, osOverride, shell, exportTarget, exportFullPath = "", "", "", "", false })
+	live := "/tmp/pathuni/usr/games:/tmp/pathuni/usr/bin:/tmp/pathuni/usr/local/bin"
+	t.Setenv("PATH", live)
+
+	// The inherited PATH is referenced, not written into the file
+	exportTarget = "environment.d"
+	if out := captureOutput(func() { _ = runExport() }); !strings.HasSuffix(out, "\nPATH=/tmp/pathuni/usr/local/bin:${PATH}\n") {
+		t.Errorf("unexpected environment.d output:\n%s", out)
+	}
+	exportTarget = "profile.d"
+	code := captureOutput(func() { _ = runExport() })
+	if strings.Contains(code, "/tmp/pathuni/usr/bin") {
+		t.Errorf("profile.d should not contain the inherited PATH:\n%s", code)
+	}
+	if sh, err := exec.LookPath("sh"); err == nil {
+		cmd := exec.Command(sh, "-c", code+`printf %s "$PATH"`)
+		cmd.Env = []string{"PATH=" + live}
+		got, err := cmd.Output()
+		if err != nil || string(got) != "/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin" {
+			t.Errorf("sourcing profile.d gave %q (%v)\n%s", got, err, code)
+		}
+	}
+
+	exportFullPath = true
+	if out := captureOutput(func() { _ = runExport() }); !strings.Contains(out, "export PATH='/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin'\n") {
+		t.Errorf("--full-path should render the expanded PATH, got:\n%s", out)
+	}
+	exportTarget = "dockerfile"
+	if err := runExport(); err == nil || !strings.Contains(err.Error(), "--full-path applies to environment.d, profile.d") {
+		t.Errorf("expected --full-path to be rejected for dockerfile, got %v", err)
+	}
+}
+
+func TestExport_Write(t *testing.T) {
+	setupTestFilesystem(t)
+
+	config = filepath.Join(t.TempDir(), "export.yaml")
+	writeFile(t, config, "all:\n  paths:\n    - /tmp/pathuni/usr/local/bin\n")
+	osOverride, shell, scope, prune = "Linux", "bash", "pathuni", "pathuni"
+	exportTarget, exportWrite, exportFullPath = "environment.d", true, true
+	t.Cleanup(func() {
+		config, osOverride, shell, scope, exportTarget, exportWrite, exportFullPath = "", "", "", "full", "", false, false
+	})
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	file := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "environment.d", "50-pathuni.conf")
+	writeFile(t, file, "PATH=/old\n")
+	os.Chmod(file, 0600)
+
+	out := captureOutput(func() {
+		if err := runExport(); err != nil {
+			t.Errorf("export: %v", err)
+		}
+	})
+	if !strings.Contains(out, "- PATH=/old\n") || !strings.Contains(out, "+ PATH=/tmp/pathuni/usr/local/bin\n") || !strings.HasSuffix(out, "Wrote "+file+"\n") {
+		t.Errorf("unexpected write output:\n%s", out)
+	}
+	data, _ := os.ReadFile(file)
+	if string(data) != renderEnvironmentD([]string{"/tmp/pathuni/usr/local/bin"}) {
+		t.Errorf("unexpected file content:\n%s", data)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("permissions not kept: %v", info.Mode())
+	}
+
+	out = captureOutput(func() { _ = runExport() })
+	if out != file+" is up to date\n" {
+		t.Errorf("expected no change, got:\n%s", out)
+	}
+
+	exportTarget = "cron"
+	if err := runExport(); err == nil || !strings.Contains(err.Error(), "invalid target 'cron'") {
+		t.Errorf("expected invalid target error, got %v", err)
+	}
+}
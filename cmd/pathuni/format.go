@@ -0,0 +1,174 @@
+package main
+
+// Config file encodings. The decoder is picked by extension: .toml files are
+// decoded with go-toml, everything else (.yaml, .yml, .json) with the YAML
+// parser, JSON being a subset of YAML. Every encoding ends up as a yaml.Node,
+// so strict checking, the string-or-object path union and tag inheritance
+// (absent vs empty `tags`) behave the same whatever the file format.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configFormats are the supported encodings, by file extension.
+var configFormats = map[string]string{
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".json": "json",
+}
+
+// configFormat returns the encoding of a config file, defaulting to YAML for
+// unknown extensions.
+func configFormat(path string) string {
+	if format, ok := configFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	return "yaml"
+}
+
+// parseConfigDocument parses a config file of any supported encoding into a
+// YAML document node. TOML documents carry no positions.
+func parseConfigDocument(path string, data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if configFormat(path) != "toml" {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	}
+
+	var values map[string]interface{}
+	if err := toml.Unmarshal(data, &values); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, col := decodeErr.Position()
+			return nil, fmt.Errorf("toml: line %d, column %d: %v", row, col, err)
+		}
+		return nil, fmt.Errorf("toml: %v", err)
+	}
+	if len(values) == 0 {
+		return &doc, nil
+	}
+	var root yaml.Node
+	if err := root.Encode(values); err != nil {
+		return nil, err
+	}
+	doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&root}}
+	return &doc, nil
+}
+
+// plainConfigValue returns a document as plain maps and slices for the
+// encoders that cannot take a yaml.Node. Anchors and merge keys are resolved;
+// null values are dropped since TOML cannot express them.
+func plainConfigValue(doc *yaml.Node) (interface{}, error) {
+	var value interface{}
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+	return dropNulls(value), nil
+}
+
+func dropNulls(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if child == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = dropNulls(child)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = dropNulls(child)
+		}
+	}
+	return v
+}
+
+// encodeConfigDocument renders a document in the given encoding. YAML keeps
+// comments and key order; JSON and TOML output has sorted keys.
+func encodeConfigDocument(doc *yaml.Node, format string) ([]byte, error) {
+	if format == "yaml" {
+		return encodeConfigNode(doc)
+	}
+	value, err := plainConfigValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "toml":
+		return toml.Marshal(value)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+var convertTo string
+
+var configConvertCmd = &cobra.Command{
+	Use:   "convert [file]",
+	Short: "Print the config converted to another format",
+	Long: `Print a config file converted to YAML, TOML or JSON.
+
+Without a file argument the config given by --config (or discovery) is used.
+Comments are only kept when converting to YAML.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		file := getConfigPath()
+		if len(args) == 1 {
+			file = args[0]
+		}
+		out, err := convertConfigFile(file, convertTo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+// convertConfigFile validates a single config file and re-encodes it.
+func convertConfigFile(file, format string) ([]byte, error) {
+	switch format {
+	case "yaml", "toml", "json":
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'. Supported formats: yaml, toml, json", format)
+	}
+	if len(filepath.SplitList(file)) != 1 {
+		return nil, fmt.Errorf("convert takes a single config file, got %q", file)
+	}
+	if _, err := loadConfig(file); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+	doc, err := parseConfigDocument(file, data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+	return encodeConfigDocument(doc, format)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reencodeTestdata writes a YAML testdata file in another encoding.
+func reencodeTestdata(t *testing.T, src, format string) string {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("read %s: %v", src, err)
+	}
+	doc, err := parseConfigDocument(src, data)
+	if err != nil {
+		t.Fatalf("parse %s: %v", src, err)
+	}
+	out, err := encodeConfigDocument(doc, format)
+	if err != nil {
+		t.Fatalf("encode %s as %s: %v", src, format, err)
+	}
+	dst := filepath.Join(t.TempDir(), strings.TrimSuffix(filepath.Base(src), ".yaml")+"."+format)
+	writeFile(t, dst, string(out))
+	return dst
+}
+
+// The testdata suite is written in YAML; every file must load and evaluate
+// identically once converted to TOML and JSON.
+func TestFormat_TestdataSuiteAcrossEncodings(t *testing.T) {
+	setupTestFilesystem(t)
+
+	files, err := filepath.Glob(filepath.Join("testdata", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no testdata found: %v", err)
+	}
+	for _, src := range files {
+		if _, err := readConfigFiles(src); err != nil {
+			continue // invalid YAML on purpose, nothing to convert
+		}
+		wantCfg, wantErr := loadConfig(src)
+		for _, format := range []string{"toml", "json"} {
+			t.Run(filepath.Base(src)+"/"+format, func(t *testing.T) {
+				converted := reencodeTestdata(t, src, format)
+
+				gotCfg, gotErr := loadConfig(converted)
+				if (gotErr == nil) != (wantErr == nil) {
+					t.Fatalf("load error mismatch: yaml %v, %s %v", wantErr, format, gotErr)
+				}
+				if wantErr != nil {
+					return
+				}
+				if !reflect.DeepEqual(gotCfg, wantCfg) {
+					t.Fatalf("config mismatch:\n yaml: %#v\n %s: %#v", wantCfg, format, gotCfg)
+				}
+				for _, platform := range []string{"macOS", "Linux"} {
+					want, _, err1 := EvaluateConfigDetailed(src, platform, "bash", TagFilter{})
+					got, _, err2 := EvaluateConfigDetailed(converted, platform, "bash", TagFilter{})
+					if err1 != nil || err2 != nil || !reflect.DeepEqual(got, want) {
+						t.Errorf("%s evaluation mismatch (%v, %v):\n yaml: %v\n %s: %v", platform, err1, err2, want, format, got)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestFormat_TagInheritance(t *testing.T) {
+	dir := t.TempDir()
+	tomlPath := filepath.Join(dir, "config.toml")
+	writeFile(t, tomlPath, `[all]
+tags = ["base"]
+paths = [
+  "/usr/bin",
+  { path = "/opt/a", tags = [] },
+  { path = "/opt/b" },
+]
+`)
+	jsonPath := filepath.Join(dir, "config.json")
+	writeFile(t, jsonPath, `{"all": {"tags": ["base"], "paths": ["/usr/bin", {"path": "/opt/a", "tags": []}, {"path": "/opt/b"}]}}`)
+
+	for _, path := range []string{tomlPath, jsonPath} {
+		cfg, err := loadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		entries, err := extractPathEntries(cfg.All.Paths, "all")
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(entries) != 3 {
+			t.Fatalf("%s: expected 3 entries, got %d", path, len(entries))
+		}
+		if tags := entries[1].GetEffectiveTags(cfg.All.Tags); tags == nil || len(tags) != 0 {
+			t.Errorf("%s: empty tags should stay empty, got %v", path, tags)
+		}
+		if tags := entries[2].GetEffectiveTags(cfg.All.Tags); len(tags) != 1 || tags[0] != "base" {
+			t.Errorf("%s: absent tags should inherit, got %v", path, tags)
+		}
+	}
+}
+
+func TestFormat_TOMLErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.toml")
+	writeFile(t, bad, "[all]\npaths = [\"/usr/bin\"\n")
+	if _, err := loadConfig(bad); err == nil || !strings.Contains(err.Error(), "toml: line") {
+		t.Errorf("expected positioned TOML syntax error, got %v", err)
+	}
+
+	typo := filepath.Join(dir, "typo.toml")
+	writeFile(t, typo, "[all]\ntag = [\"dev\"]\n")
+	want := typo + `: unknown field "tag" in all (did you mean "tags"?)`
+	if _, err := loadConfig(typo); err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
+
+func TestFormat_Convert(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "my_paths.yaml")
+	writeFile(t, src, `all:
+  tags: [base]
+  paths:
+    - /usr/local/bin
+    - path: /opt/tools
+      tags: []
+      position: append
+`)
+	out, err := convertConfigFile(src, "toml")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	want := `[all]
+paths = ['/usr/local/bin', {path = '/opt/tools', position = 'append', tags = []}]
+tags = ['base']
+`
+	if string(out) != want {
+		t.Errorf("unexpected TOML:\n got:\n%s\nwant:\n%s", out, want)
+	}
+
+	if _, err := convertConfigFile(src, "ini"); err == nil || !strings.Contains(err.Error(), "unsupported format 'ini'") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
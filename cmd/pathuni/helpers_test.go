@@ -1,15 +1,17 @@
 package main
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestHelpers_ReadPathsFile(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
+	withTestRoot(t, "testdata")
 	
 	tests := []struct {
 		name        string
@@ -45,7 +47,7 @@ func TestHelpers_ReadPathsFile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			filePath := filepath.Join("testdata", tt.filename)
+			filePath := "/" + tt.filename
 			
 			paths, err := readPathsFile(filePath)
 			
@@ -77,19 +79,18 @@ func TestHelpers_ReadPathsFile(t *testing.T) {
 	}
 }
 
-// (Removed) getTestSystemPaths: tests now use the production getSystemPaths via
-// the PATHUNI_TEST_SYSTEM_PATHS_ROOT seam. Keeping code DRY and consistent.
+// (Removed) getTestSystemPaths: tests now use the production getSystemPaths
+// against an in-memory target filesystem. Keeping code DRY and consistent.
 
 // Test helper function that mimics getShellSpecificPaths but uses test data
-func getTestShellSpecificPaths(shell string, platformConfig PlatformConfig) []string {
-    // Ensure seam is enabled, then call production helper
-    os.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+func getTestShellSpecificPaths(t *testing.T, shell string, platformConfig PlatformConfig) []string {
+    // Read testdata as the target filesystem, then call production helper
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
     return getShellSpecificPaths(shell, platformConfig)
 }
 
 func TestHelpers_GetShellSpecificPaths(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
     
     tests := []struct {
         name           string
@@ -152,7 +153,7 @@ func TestHelpers_GetShellSpecificPaths(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			paths := getTestShellSpecificPaths(tt.shell, tt.platformConfig)
+			paths := getTestShellSpecificPaths(t, tt.shell, tt.platformConfig)
 			
 			if tt.expectPaths && len(paths) == 0 {
 				t.Errorf("Expected some system paths for %s, got none", tt.name)
@@ -166,11 +167,11 @@ func TestHelpers_GetShellSpecificPaths(t *testing.T) {
 }
 
 // Test helper function that mimics countValidSystemPaths but uses test data
-func countTestValidSystemPaths(shell string, platformConfig PlatformConfig) int {
+func countTestValidSystemPaths(t *testing.T, shell string, platformConfig PlatformConfig) int {
     if shell != "powershell" || platformConfig.PowerShell == nil || !platformConfig.PowerShell.IncludeSystemPaths {
         return 0
     }
-    os.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
     systemPaths, err := getSystemPaths()
 	if err != nil {
 		return 0
@@ -179,7 +180,7 @@ func countTestValidSystemPaths(shell string, platformConfig PlatformConfig) int
 	validCount := 0
 	for _, path := range systemPaths {
 		expanded := os.ExpandEnv(path)
-		if info, err := os.Stat(expanded); err == nil && info.IsDir() {
+		if info, err := fs.Stat(targetFS(), fsName(expanded)); err == nil && info.IsDir() {
 			validCount++
 		}
 	}
@@ -189,7 +190,6 @@ func countTestValidSystemPaths(shell string, platformConfig PlatformConfig) int
 
 func TestHelpers_CountValidSystemPaths(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	tests := []struct {
 		name           string
@@ -237,7 +237,7 @@ func TestHelpers_CountValidSystemPaths(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			count := countTestValidSystemPaths(tt.shell, tt.platformConfig)
+			count := countTestValidSystemPaths(t, tt.shell, tt.platformConfig)
 			
 			if tt.expectCount == -1 {
 				// Just check that we got some paths
@@ -255,8 +255,7 @@ func TestHelpers_CountValidSystemPaths(t *testing.T) {
 
 // Test the file reading functionality with edge cases
 func TestHelpers_ReadPathsFileEdgeCases(t *testing.T) {
-	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
+	fsys := setupTestFilesystem(t)
 	
 	// Create a temporary test file with various edge cases
 	testContent := `# Comment at the start
@@ -271,20 +270,11 @@ func TestHelpers_ReadPathsFileEdgeCases(t *testing.T) {
 /final/path
 # Comment at end`
 	
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("/tmp/pathuni/home/Pratt/.config/pathuni", "pathuni-test-*.txt")
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
-	}
-	defer os.Remove(tmpFile.Name())
-	
-	if _, err := tmpFile.WriteString(testContent); err != nil {
-		t.Fatalf("Failed to write test content: %v", err)
-	}
-	tmpFile.Close()
+	// Add the test file to the target filesystem
+	fsys["tmp/pathuni/home/Pratt/.config/pathuni/pathuni-test.txt"] = &fstest.MapFile{Data: []byte(testContent)}
 	
 	// Read the file
-	paths, err := readPathsFile(tmpFile.Name())
+	paths, err := readPathsFile("/tmp/pathuni/home/Pratt/.config/pathuni/pathuni-test.txt")
 	if err != nil {
 		t.Fatalf("Unexpected error reading file: %v", err)
 	}
@@ -331,10 +321,10 @@ func TestHelpers_ReadPathsFileErrors(t *testing.T) {
 // Integration test that mimics getSystemPaths behavior with our test data
 func TestHelpers_SystemPathsIntegration(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
+	withTestRoot(t, filepath.Join("testdata", "system_paths"))
 	
     // This test simulates what getSystemPaths would do with our test data (etc layout)
-    testDataDir := filepath.Join("testdata", "system_paths", "etc")
+    testDataDir := "/etc"
 	
 	// Read the main paths file
 	pathsFile := filepath.Join(testDataDir, "paths")
@@ -345,7 +335,7 @@ func TestHelpers_SystemPathsIntegration(t *testing.T) {
 	
     // Read files from paths.d directory
     pathsDDir := filepath.Join(testDataDir, "paths.d")
-	entries, err := os.ReadDir(pathsDDir)
+	entries, err := readDirPath(pathsDDir)
 	if err != nil {
 		t.Fatalf("Failed to read paths.d directory: %v", err)
 	}
@@ -391,8 +381,7 @@ func TestHelpers_SystemPathsIntegration(t *testing.T) {
 
 // Test path trimming and comment filtering
 func TestHelpers_PathProcessing(t *testing.T) {
-	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
+	fsys := setupTestFilesystem(t)
 	
 	// Create a test file with various whitespace and comment scenarios
 	testCases := []string{
@@ -406,19 +395,10 @@ func TestHelpers_PathProcessing(t *testing.T) {
 		"/path/after/empty/lines",
 	}
 	
-	tmpFile, err := os.CreateTemp("/tmp/pathuni/home/Pratt/.config/pathuni", "pathuni-whitespace-test-*.txt")
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
-	}
-	defer os.Remove(tmpFile.Name())
-	
 	content := strings.Join(testCases, "\n")
-	if _, err := tmpFile.WriteString(content); err != nil {
-		t.Fatalf("Failed to write test content: %v", err)
-	}
-	tmpFile.Close()
+	fsys["tmp/pathuni/home/Pratt/.config/pathuni/pathuni-whitespace-test.txt"] = &fstest.MapFile{Data: []byte(content)}
 	
-	paths, err := readPathsFile(tmpFile.Name())
+	paths, err := readPathsFile("/tmp/pathuni/home/Pratt/.config/pathuni/pathuni-whitespace-test.txt")
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -0,0 +1,262 @@
+package main
+
+// Directory-local PATH activation. `pathuni hook <shell>` installs a prompt or
+// directory-change hook that runs `pathuni hook-env` each time; hook-env finds
+// the nearest .pathuni.yaml, adds its paths on entry and removes them again on
+// exit. Like the project layer of config discovery, project files are only
+// honoured once trusted with `pathuni allow`. While the hook is installed,
+// discovery leaves project files to it so they are not also layered into init.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Environment variables carrying the hook state between prompts.
+const (
+	hookFileVar  = "PATHUNI_DIR_FILE"  // Active project file
+	hookStateVar = "PATHUNI_DIR_STATE" // Content hash, prefixed with "!" when not allowed
+	hookAddedVar = "PATHUNI_DIR_ADDED" // Entries added to PATH by the hook
+	hookShellVar = "PATHUNI_HOOK"      // Set by the hook installer
+)
+
+// projectPaths evaluates a project config and returns its existing paths in
+// PATH order. Relative entries are resolved against the file's directory.
+func projectPaths(file, platform, shell string) ([]string, error) {
+	tagFilter, err := parseTagFlags(tagsInclude, tagsExclude)
+	if err != nil {
+		return nil, err
+	}
+	statuses, _, err := evaluateConfigIn(file, filepath.Dir(file), platform, shell, tagFilter)
+	if err != nil {
+		return nil, err
+	}
+	return mergePlaced(placedFromStatuses(statuses, true), nil), nil
+}
+
+// hookUpdate is the environment change produced by one hook-env run.
+type hookUpdate struct {
+	Path []string
+	Vars [][2]string // name/value pairs; an empty value unsets the variable
+}
+
+// computeHookUpdate works out the PATH and hook state for cwd given the
+// current PATH and the previous hook state. It returns false when nothing
+// changed since the last run.
+func computeHookUpdate(cwd string, current []string, env func(string) string, platform, shell string) (hookUpdate, bool, error) {
+	file, found := findProjectConfig(cwd)
+	state := ""
+	if found {
+		hash, err := fileHash(file)
+		if err != nil {
+			return hookUpdate{}, false, err
+		}
+		state = hash
+		if readAllowList()[file] != hash {
+			state = "!" + hash
+		}
+	}
+	if file == env(hookFileVar) && state == env(hookStateVar) {
+		return hookUpdate{}, false, nil
+	}
+
+	// Drop what the previous project added, restoring the PATH from before
+	removed := make(map[string]bool)
+	for _, p := range filepath.SplitList(env(hookAddedVar)) {
+		removed[p] = true
+	}
+	var path []string
+	for _, p := range current {
+		if !removed[p] {
+			path = append(path, p)
+		}
+	}
+
+	var added []string
+	switch {
+	case !found:
+	case strings.HasPrefix(state, "!"):
+		fmt.Fprintf(os.Stderr, "pathuni: %s is not allowed. Run 'pathuni allow' to trust it.\n", file)
+	default:
+		entries, err := projectPaths(file, platform, shell)
+		if err != nil {
+			return hookUpdate{}, false, err
+		}
+		present := make(map[string]bool, len(path))
+		for _, p := range path {
+			present[p] = true
+		}
+		for _, p := range entries {
+			if !present[p] {
+				present[p] = true
+				added = append(added, p)
+			}
+		}
+		if len(added) > 0 {
+			fmt.Fprintf(os.Stderr, "pathuni: loaded %s\n", file)
+		}
+	}
+
+	return hookUpdate{
+		Path: append(added, path...),
+		Vars: [][2]string{
+			{hookFileVar, file},
+			{hookStateVar, state},
+			{hookAddedVar, strings.Join(added, string(os.PathListSeparator))},
+		},
+	}, true, nil
+}
+
+// renderHookUpdate renders a hookUpdate as code for the given shell.
+func renderHookUpdate(shell string, u hookUpdate) string {
+	var lines []string
+	switch shell {
+	case "fish":
+		quoted := make([]string, 0, len(u.Path))
+		for _, p := range u.Path {
+			quoted = append(quoted, fishQuote(p))
+		}
+		lines = append(lines, "set -gx PATH "+strings.Join(quoted, " "))
+		for _, v := range u.Vars {
+			if v[1] == "" {
+				lines = append(lines, "set -e "+v[0])
+			} else {
+				lines = append(lines, fmt.Sprintf("set -gx %s %s", v[0], fishQuote(v[1])))
+			}
+		}
+	case "powershell":
+		lines = append(lines, "$env:PATH = "+pwshQuote(strings.Join(u.Path, ":")))
+		for _, v := range u.Vars {
+			if v[1] == "" {
+				lines = append(lines, fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", v[0]))
+			} else {
+				lines = append(lines, fmt.Sprintf("$env:%s = %s", v[0], pwshQuote(v[1])))
+			}
+		}
+	default:
+		lines = append(lines, "export PATH="+shQuote(strings.Join(u.Path, ":")))
+		for _, v := range u.Vars {
+			if v[1] == "" {
+				lines = append(lines, "unset "+v[0])
+			} else {
+				lines = append(lines, fmt.Sprintf("export %s=%s", v[0], shQuote(v[1])))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hookScripts holds the hook installers, keyed by shell. %s is the quoted
+// pathuni executable.
+var hookScripts = map[string]string{
+	"bash": `export PATHUNI_HOOK=bash
+_pathuni_hook() {
+  local previous_exit_status=$?
+  eval "$(%[1]s hook-env --shell bash)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND[*]:-};" != *";_pathuni_hook;"* ]]; then
+  PROMPT_COMMAND="_pathuni_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi`,
+	"zsh": `export PATHUNI_HOOK=zsh
+_pathuni_hook() {
+  eval "$(%[1]s hook-env --shell zsh)"
+}
+typeset -ag chpwd_functions
+if (( ! ${chpwd_functions[(I)_pathuni_hook]} )); then
+  chpwd_functions=(_pathuni_hook $chpwd_functions)
+fi
+_pathuni_hook`,
+	"fish": `set -gx PATHUNI_HOOK fish
+function __pathuni_hook --on-variable PWD
+    %[1]s hook-env --shell fish | source
+end
+__pathuni_hook`,
+	"powershell": `$env:PATHUNI_HOOK = 'powershell'
+$global:__pathuniPrompt = $function:prompt
+function global:prompt {
+    $hook = & %[1]s hook-env --shell powershell | Out-String
+    if ($hook) { Invoke-Expression $hook }
+    & $global:__pathuniPrompt
+}`,
+}
+
+// hookShellNames returns the shells with a hook installer, sorted.
+func hookShellNames() []string {
+	names := make([]string, 0, len(hookScripts))
+	for name := range hookScripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderHookScript returns the hook installer for shell, calling exe.
+func renderHookScript(shell, exe string) (string, error) {
+	script, ok := hookScripts[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell '%s' for hook. Supported shells: %s", shell, strings.Join(hookShellNames(), ", "))
+	}
+	quoted := shQuote(exe)
+	switch shell {
+	case "fish":
+		quoted = fishQuote(exe)
+	case "powershell":
+		quoted = pwshQuote(exe)
+	}
+	return fmt.Sprintf(script, quoted), nil
+}
+
+var hookCmd = &cobra.Command{
+	Use:         "hook <shell>",
+	Short:       "Print a shell hook that activates .pathuni.yaml files per directory",
+	Args:        cobra.ExactArgs(1),
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		exe, err := os.Executable()
+		if err != nil {
+			exe = "pathuni"
+		}
+		script, err := renderHookScript(normalizeShellName(strings.ToLower(args[0])), exe)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(script)
+	},
+}
+
+var hookEnvCmd = &cobra.Command{
+	Use:         "hook-env",
+	Short:       "Print the PATH update for the current directory (used by the hook)",
+	Hidden:      true,
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		runHookEnv()
+	},
+}
+
+func runHookEnv() {
+	osName, _ := getOSName()
+	shellName, _ := getShellName()
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pathuni: %v\n", err)
+		return
+	}
+	current, _ := getCurrentPath()
+	update, changed, err := computeHookUpdate(cwd, current, os.Getenv, osName, shellName)
+	if err != nil {
+		// Never break the prompt; report and leave the environment alone
+		fmt.Fprintf(os.Stderr, "pathuni: %v\n", err)
+		return
+	}
+	if changed {
+		fmt.Println(renderHookUpdate(shellName, update))
+	}
+}
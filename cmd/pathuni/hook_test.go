@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupHookProject creates a project with a .pathuni.yaml using relative
+// paths, and isolates the allowlist.
+func setupHookProject(t *testing.T) (root, file string) {
+	t.Helper()
+	root = t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(t.TempDir(), "data"))
+	for _, dir := range []string{"node_modules/.bin", "bin", "src/deep"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	file = filepath.Join(root, projectConfigName)
+	writeFile(t, file, "all:\n  paths:\n    - ./node_modules/.bin\n    - ./bin\n    - ./.venv/bin\n")
+	tagsInclude, tagsExclude = "", ""
+	return root, file
+}
+
+// hookEnv turns a hookUpdate's variables into a lookup function, emulating
+// the shell environment on the next prompt.
+func hookEnv(u hookUpdate) func(string) string {
+	vars := make(map[string]string)
+	for _, v := range u.Vars {
+		vars[v[0]] = v[1]
+	}
+	return func(name string) string { return vars[name] }
+}
+
+func TestHook_EnterAllowLeave(t *testing.T) {
+	root, file := setupHookProject(t)
+	base := []string{"/usr/bin", "/bin"}
+	noState := func(string) string { return "" }
+
+	// Untrusted: nothing is added, but the state is recorded so the warning
+	// is not repeated on every prompt
+	u, changed, err := computeHookUpdate(filepath.Join(root, "src", "deep"), base, noState, "Linux", "bash")
+	if err != nil || !changed {
+		t.Fatalf("expected an update, got changed=%v err=%v", changed, err)
+	}
+	if !reflect.DeepEqual(u.Path, base) || !strings.HasPrefix(hookEnv(u)(hookStateVar), "!") {
+		t.Errorf("untrusted project should not change PATH: %+v", u)
+	}
+	if _, changed, _ := computeHookUpdate(root, u.Path, hookEnv(u), "Linux", "bash"); changed {
+		t.Error("unchanged untrusted project should not produce an update")
+	}
+
+	if err := runAllowQuiet(t, file); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	prev := u
+	u, changed, err = computeHookUpdate(filepath.Join(root, "src", "deep"), prev.Path, hookEnv(prev), "Linux", "bash")
+	if err != nil || !changed {
+		t.Fatalf("expected an update after allow, got changed=%v err=%v", changed, err)
+	}
+	want := []string{filepath.Join(root, "node_modules/.bin"), filepath.Join(root, "bin"), "/usr/bin", "/bin"}
+	if !reflect.DeepEqual(u.Path, want) {
+		t.Errorf("entering project\n got: %v\nwant: %v", u.Path, want)
+	}
+
+	// Leaving restores the previous PATH, keeping entries added meanwhile
+	inside := append([]string{"/opt/extra"}, u.Path...)
+	u, changed, err = computeHookUpdate(t.TempDir(), inside, hookEnv(u), "Linux", "bash")
+	if err != nil || !changed {
+		t.Fatalf("expected an update on leave, got changed=%v err=%v", changed, err)
+	}
+	if !reflect.DeepEqual(u.Path, []string{"/opt/extra", "/usr/bin", "/bin"}) {
+		t.Errorf("leaving project left PATH at %v", u.Path)
+	}
+	if hookEnv(u)(hookFileVar) != "" || hookEnv(u)(hookAddedVar) != "" {
+		t.Errorf("hook state should be cleared on leave: %+v", u.Vars)
+	}
+}
+
+func TestHook_EditRevokesTrust(t *testing.T) {
+	root, file := setupHookProject(t)
+	if err := runAllowQuiet(t, file); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	if !isAllowed(file) {
+		t.Fatal("expected file to be allowed")
+	}
+	writeFile(t, file, "all:\n  paths:\n    - /tmp/evil\n")
+	if isAllowed(file) {
+		t.Error("editing the file should revoke trust")
+	}
+	u, _, err := computeHookUpdate(root, []string{"/bin"}, func(string) string { return "" }, "Linux", "bash")
+	if err != nil {
+		t.Fatalf("computeHookUpdate: %v", err)
+	}
+	if !reflect.DeepEqual(u.Path, []string{"/bin"}) {
+		t.Errorf("edited file should not be loaded, got %v", u.Path)
+	}
+
+	allowRevoke = true
+	defer func() { allowRevoke = false }()
+	if err := runAllowQuiet(t, file); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, ok := readAllowList()[file]; ok {
+		t.Error("revoke should remove the file from the allowlist")
+	}
+}
+
+func runAllowQuiet(t *testing.T, file string) error {
+	t.Helper()
+	var err error
+	captureOutput(func() { err = runAllow([]string{file}) })
+	return err
+}
+
+func TestHook_RenderedCodeRuns(t *testing.T) {
+	u := hookUpdate{
+		Path: []string{"/opt/it's", "/usr/bin"},
+		Vars: [][2]string{{hookFileVar, "/repo/.pathuni.yaml"}, {hookAddedVar, ""}},
+	}
+	code := renderHookUpdate("bash", u)
+	for _, sh := range []string{"sh", "bash", "dash"} {
+		bin, err := exec.LookPath(sh)
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(bin, "-c", code+"\n"+`printf '%s|%s|%s' "$PATH" "$PATHUNI_DIR_FILE" "${PATHUNI_DIR_ADDED-unset}"`)
+		cmd.Env = []string{"PATH=/bin", "PATHUNI_DIR_ADDED=/old"}
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", sh, err, code)
+		}
+		if string(out) != "/opt/it's:/usr/bin|/repo/.pathuni.yaml|unset" {
+			t.Errorf("%s: unexpected result %q", sh, out)
+		}
+	}
+
+	if got := renderHookUpdate("fish", u); got != "set -gx PATH '/opt/it\\'s' '/usr/bin'\nset -gx PATHUNI_DIR_FILE '/repo/.pathuni.yaml'\nset -e PATHUNI_DIR_ADDED" {
+		t.Errorf("unexpected fish code: %q", got)
+	}
+	if got := renderHookUpdate("powershell", u); !strings.Contains(got, "$env:PATH = '/opt/it''s:/usr/bin'") || !strings.Contains(got, "Remove-Item Env:PATHUNI_DIR_ADDED") {
+		t.Errorf("unexpected PowerShell code: %q", got)
+	}
+}
+
+func TestHook_Scripts(t *testing.T) {
+	for _, sh := range hookShellNames() {
+		script, err := renderHookScript(sh, "/usr/local/bin/pathuni")
+		if err != nil || !strings.Contains(script, "hook-env --shell "+sh) || !strings.Contains(script, "PATHUNI_HOOK") {
+			t.Errorf("%s: unexpected hook script (err=%v):\n%s", sh, err, script)
+		}
+	}
+	if _, err := renderHookScript("dash", "pathuni"); err == nil {
+		t.Error("expected error for a shell without hook support")
+	}
+
+	if bash, err := exec.LookPath("bash"); err == nil {
+		script, _ := renderHookScript("bash", "/usr/local/bin/pathuni")
+		out, err := exec.Command(bash, "-c", script+"\n"+script+"\nprintf %s \"$PROMPT_COMMAND\"").Output()
+		if err != nil {
+			t.Fatalf("bash hook script failed: %v", err)
+		}
+		if string(out) != "_pathuni_hook" {
+			t.Errorf("hook should install once, PROMPT_COMMAND=%q", out)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefer_IdempotentReevaluation(t *testing.T) {
+	setupTestFilesystem(t)
+
+	config = filepath.Join(t.TempDir(), "idempotent.yaml")
+	writeFile(t, config, `all:
+  paths:
+    - /tmp/pathuni/usr/local/bin
+    - /tmp/pathuni/home/Pratt/bin
+    - path: /tmp/pathuni/opt/tools
+      position: append
+`)
+	osOverride, prune, scope = "Linux", "pathuni", "full"
+	tagsInclude, tagsExclude = "", ""
+	t.Cleanup(func() { config, osOverride, shell, deferEnv = "", "", "", false })
+
+	// The live PATH already holds a pathuni entry in the wrong place
+	livePath := "/tmp/pathuni/usr/bin:/tmp/pathuni/opt/tools:/tmp/pathuni/bin"
+	t.Setenv("PATH", livePath)
+	want := "/tmp/pathuni/usr/local/bin:/tmp/pathuni/home/Pratt/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/bin:/tmp/pathuni/opt/tools"
+
+	for _, sh := range []string{"sh", "bash", "dash"} {
+		bin, err := exec.LookPath(sh)
+		if err != nil {
+			continue
+		}
+		shell = sh
+		deferEnv = true
+		code := captureOutput(runInit)
+		deferEnv = false
+
+		for n := 1; n <= 3; n++ {
+			script := strings.Repeat(code, n) + `printf %s "$PATH"`
+			cmd := exec.Command(bin, "-c", script)
+			cmd.Env = []string{"PATH=" + livePath}
+			got, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%s: running generated code failed: %v\n%s", sh, err, code)
+			}
+			if string(got) != want {
+				t.Errorf("%s: PATH after %d evaluations = %q, want %q", sh, n, got, want)
+			}
+		}
+	}
+}
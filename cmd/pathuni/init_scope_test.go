@@ -25,7 +25,6 @@ func captureOutput(f func()) string {
 
 func TestInit_Scopes_Render_Bash(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
     // Reset globals to safe defaults for this test
     prune = "pathuni"
@@ -75,7 +74,10 @@ func TestInit_Scopes_Render_Bash(t *testing.T) {
     deferEnv = true
     scope = "full"
     out = captureOutput(runInit)
-    expected = "export PATH=\"" + expectedPathuni + ":${PATH}\"\n"
+    strip := `__pathuni_r="${PATH}:"; __pathuni_p=; while [ -n "$__pathuni_r" ]; do __pathuni_e="${__pathuni_r%%:*}"; __pathuni_r="${__pathuni_r#*:}"; __pathuni_k=1; ` +
+        `case "$__pathuni_e" in '/tmp/pathuni/usr/local/bin'|'/tmp/pathuni/home/Pratt/.local/bin'|'/tmp/pathuni/usr/bin'|'/tmp/pathuni/opt/homebrew/bin'|'/tmp/pathuni/opt/homebrew/sbin'|'/tmp/pathuni/home/Pratt/.cargo/bin'|'/tmp/pathuni/Applications/Docker.app/Contents/Resources/bin') __pathuni_k= ;; esac; ` +
+        `[ -z "$__pathuni_k" ] || __pathuni_p="$__pathuni_p:$__pathuni_e"; done; PATH="${__pathuni_p#:}"; unset __pathuni_r __pathuni_p __pathuni_e __pathuni_k`
+    expected = strip + "\nexport PATH=\"" + expectedPathuni + ":${PATH}\"\n"
     if out != expected {
         t.Errorf("full scope defer-env render mismatch:\nwant: %q\n got: %q", expected, out)
     }
@@ -103,7 +105,7 @@ func TestInit_Scopes_Render_Bash(t *testing.T) {
 
     // Now test pathuni list includes missing entries when prune=none|system
     // Create a temporary config with one existing and one missing path
-    tmpCfg, err := os.CreateTemp("/tmp/pathuni/home/Pratt/.config/pathuni", "cfg-*.yaml")
+    tmpCfg, err := os.CreateTemp(t.TempDir(), "cfg-*.yaml")
     if err != nil { t.Fatalf("temp cfg: %v", err) }
     defer os.Remove(tmpCfg.Name())
     cfgContent := "all:\n  paths:\n    - \"/tmp/pathuni/usr/local/bin\"\n    - \"/tmp/pathuni/does-not-exist\"\n"
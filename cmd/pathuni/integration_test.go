@@ -7,7 +7,6 @@ import (
 
 func TestIntegration_TagFiltering(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 	
 	testConfigPath := filepath.Join("testdata", "integration_tag_filtering.yaml")
 
@@ -0,0 +1,474 @@
+package main
+
+// `pathuni config lint`: style and correctness warnings on top of the hard
+// validation errors. The config is walked as a yaml.Node so every warning
+// carries a position and fixable warnings can be applied in place with --fix,
+// keeping comments intact.
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// lintIssue is a single lint warning. Fix is nil when the issue cannot be
+// fixed automatically.
+type lintIssue struct {
+	Line    int
+	Column  int
+	Message string
+	Fix     func()
+}
+
+// lintEntry is a path entry found while walking the config.
+type lintEntry struct {
+	Section string
+	Shell   string     // Set for entries under <section>.shells.<shell>.paths
+	Seq     *yaml.Node // The paths sequence holding the entry
+	Item    *yaml.Node // The entry itself (scalar or mapping)
+	PathN   *yaml.Node // The path scalar; nil for command entries
+	TagsN   *yaml.Node // The explicit tags sequence; nil when inherited
+	// Inherited are the tags the entry gets without explicit tags, and
+	// FromSection reports whether they are the section's own tags rather
+	// than its shell's
+	Inherited   []string
+	FromSection bool
+}
+
+// scope names where an entry lives, for messages.
+func (e lintEntry) scope() string {
+	if e.Shell != "" {
+		return e.Section + ".shells." + e.Shell
+	}
+	return e.Section
+}
+
+// osSpecificPrefixes maps path prefixes that only make sense on one OS to the
+// section they belong in.
+var osSpecificPrefixes = []struct {
+	Prefix  string
+	Section string
+}{
+	{"/opt/homebrew", "macos"},
+	{"/Applications", "macos"},
+	{"/System", "macos"},
+	{"/Library", "macos"},
+	{"/home/linuxbrew", "linux"},
+	{"/snap", "linux"},
+	{"/var/lib/flatpak", "linux"},
+}
+
+var homeDirRegex = regexp.MustCompile(`^/(?:home|Users)/[^/]+`)
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// deleteMappingKey removes key and its value from a mapping node.
+func deleteMappingKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// removeItem removes item from a sequence node.
+func removeItem(seq, item *yaml.Node) {
+	for i, n := range seq.Content {
+		if n == item {
+			seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+			return
+		}
+	}
+}
+
+// childMapping returns the mapping under key, creating it when missing.
+func childMapping(m *yaml.Node, key string) *yaml.Node {
+	child := mappingValue(m, key)
+	if child == nil {
+		child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+	}
+	return child
+}
+
+// sectionPaths returns the paths sequence of a section, or of one of its
+// shells when shell is set, creating the mappings and the sequence when
+// missing.
+func sectionPaths(root *yaml.Node, section, shell string) *yaml.Node {
+	sec := childMapping(root, section)
+	if shell != "" {
+		sec = childMapping(childMapping(sec, "shells"), shell)
+	}
+	seq := mappingValue(sec, "paths")
+	if seq == nil {
+		seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		sec.Content = append(sec.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "paths"}, seq)
+	}
+	return seq
+}
+
+// inheritedTags returns the tags an untagged entry gets in a section, or in
+// one of its shells when shell is set, and whether they are the section's.
+func inheritedTags(root *yaml.Node, section, shell string) ([]string, bool) {
+	sec := mappingValue(root, section)
+	if shell != "" {
+		sc := mappingValue(mappingValue(sec, "shells"), shell)
+		if tn := mappingValue(sc, "tags"); tn != nil && tn.Tag != "!!null" {
+			return scalarValues(tn), false
+		}
+	}
+	return scalarValues(mappingValue(sec, "tags")), true
+}
+
+// withExplicitTags gives an entry the tags it inherited so it keeps them when
+// moved to another section.
+func withExplicitTags(item *yaml.Node, tags []string) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	for _, tag := range tags {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tag})
+	}
+	if item.Kind == yaml.ScalarNode {
+		pathN := *item
+		pathN.HeadComment, pathN.LineComment, pathN.FootComment = "", "", ""
+		*item = yaml.Node{
+			Kind: yaml.MappingNode, Tag: "!!map",
+			HeadComment: item.HeadComment, LineComment: item.LineComment, FootComment: item.FootComment,
+			Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: "path"}, &pathN},
+		}
+	}
+	item.Content = append(item.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "tags"}, seq)
+}
+
+func scalarValues(n *yaml.Node) []string {
+	var out []string
+	if n == nil {
+		return out
+	}
+	for _, c := range n.Content {
+		out = append(out, c.Value)
+	}
+	return out
+}
+
+func sameTagSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// collectLintEntries walks the path entries of every platform section and of
+// its shells, each section's own paths first.
+func collectLintEntries(root *yaml.Node) []lintEntry {
+	var entries []lintEntry
+	collect := func(section, shell string, seq *yaml.Node) {
+		if seq == nil || seq.Kind != yaml.SequenceNode {
+			return
+		}
+		inherited, fromSection := inheritedTags(root, section, shell)
+		for _, item := range seq.Content {
+			e := lintEntry{Section: section, Shell: shell, Seq: seq, Item: item, Inherited: inherited, FromSection: fromSection}
+			switch item.Kind {
+			case yaml.ScalarNode:
+				e.PathN = item
+			case yaml.MappingNode:
+				e.PathN = mappingValue(item, "path")
+				e.TagsN = mappingValue(item, "tags")
+			}
+			entries = append(entries, e)
+		}
+	}
+	for _, section := range []string{"all", "linux", "macos"} {
+		sec := mappingValue(root, section)
+		collect(section, "", mappingValue(sec, "paths"))
+		shells := mappingValue(sec, "shells")
+		if shells == nil || shells.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(shells.Content); i += 2 {
+			collect(section, shells.Content[i].Value, mappingValue(shells.Content[i+1], "paths"))
+		}
+	}
+	return entries
+}
+
+// coveringScopes returns the scopes whose entries also apply wherever e
+// applies, so repeating one of their paths in e's scope is a duplicate.
+func coveringScopes(e lintEntry) []string {
+	scopes := []string{e.scope()}
+	if e.Shell != "" {
+		scopes = append(scopes, e.Section)
+	}
+	if e.Section != "all" {
+		scopes = append(scopes, "all")
+		if e.Shell != "" {
+			scopes = append(scopes, "all.shells."+e.Shell)
+		}
+	}
+	return scopes
+}
+
+// lintConfigNode returns the lint warnings for a parsed config document, for
+// the given platform. Fixes mutate the document.
+func lintConfigNode(doc *yaml.Node, platform string) []lintIssue {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	entries := collectLintEntries(root)
+	home, _ := os.UserHomeDir()
+	platformSection := strings.ToLower(platform)
+	vars := make(map[string]string)
+	if n := mappingValue(root, "vars"); n != nil {
+		_ = n.Decode(&vars)
+	}
+
+	var issues []lintIssue
+	add := func(n *yaml.Node, fix func(), format string, args ...interface{}) {
+		issues = append(issues, lintIssue{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...), Fix: fix})
+	}
+
+	seen := make(map[string]lintEntry)
+	duplicates := make(map[*yaml.Node]bool)
+	for _, e := range entries {
+		if e.PathN == nil {
+			continue // command entries have no literal path
+		}
+		e := e
+		path := e.PathN.Value
+		clean := filepath.Clean(path)
+
+		// Duplicates within a scope, or repeating an entry from a scope that
+		// also applies here ("all", or the section itself for shell paths)
+		var first lintEntry
+		for _, scope := range coveringScopes(e) {
+			if prev, dup := seen[scope+"\x00"+clean]; dup {
+				first = prev
+				break
+			}
+		}
+		if first.Item != nil {
+			var fix func()
+			if e.Item.Kind == yaml.ScalarNode {
+				fix = func() { removeItem(e.Seq, e.Item) }
+			}
+			where := first.scope()
+			if first.PathN.Line > 0 {
+				where = fmt.Sprintf("%s at line %d", where, first.PathN.Line)
+			}
+			add(e.PathN, fix, "duplicate path %q (already in %s)", path, where)
+			duplicates[e.Item] = true
+			continue
+		}
+		seen[e.scope()+"\x00"+clean] = e
+
+		// OS-specific entries in the shared section. Moving an entry changes
+		// the tags it inherits, so it takes its current ones along; an
+		// untagged entry cannot stay untagged in a tagged section.
+		osSpecific := false
+		if e.Section == "all" {
+			for _, p := range osSpecificPrefixes {
+				if path == p.Prefix || strings.HasPrefix(path, p.Prefix+"/") {
+					target := p.Section
+					osSpecific = true
+					targetTags, _ := inheritedTags(root, target, e.Shell)
+					var fix func()
+					if e.TagsN != nil || len(e.Inherited) > 0 || len(targetTags) == 0 {
+						fix = func() {
+							removeItem(e.Seq, e.Item)
+							if e.TagsN == nil && !sameTagSet(e.Inherited, targetTags) {
+								withExplicitTags(e.Item, e.Inherited)
+							}
+							seq := sectionPaths(root, target, e.Shell)
+							seq.Content = append(seq.Content, e.Item)
+						}
+					}
+					add(e.PathN, fix, "path %q is %s-specific; move it to the %s section", path, target, target)
+					break
+				}
+			}
+		}
+
+		// Hard-coded home directories
+		if m := homeDirRegex.FindString(path); m != "" && !strings.HasPrefix(path, "/home/linuxbrew") {
+			pathN := e.PathN
+			add(pathN, func() { pathN.Value = "$HOME" + strings.TrimPrefix(pathN.Value, m) }, "hard-coded home directory in %q; use $HOME", path)
+		} else if home != "" && home != "/" && strings.HasPrefix(path, home+"/") {
+			pathN := e.PathN
+			add(pathN, func() { pathN.Value = "$HOME" + strings.TrimPrefix(pathN.Value, home) }, "hard-coded home directory in %q; use $HOME", path)
+		}
+
+		// Unset variables (without a default) and invalid references
+		expanded, err := expandPath(path, vars)
+		if unset, ok := err.(*unsetVarError); ok {
+			add(e.PathN, nil, "path %q references unset variable $%s", path, unset.Name)
+		} else if err != nil {
+			add(e.PathN, nil, "path %q: %v", path, err)
+		}
+
+		// Paths that do not exist here (only sections that apply here)
+		if err == nil && !osSpecific && (e.Section == "all" || e.Section == platformSection) && !dirExists(expanded) {
+			add(e.PathN, nil, "path %q does not exist on this machine", path)
+		}
+	}
+
+	// Explicit tags identical to what would be inherited anyway; with no
+	// inherited tags that is an explicit `tags: []`
+	for _, e := range entries {
+		if e.TagsN == nil || duplicates[e.Item] || !sameTagSet(scalarValues(e.TagsN), e.Inherited) {
+			continue
+		}
+		e := e
+		add(e.TagsN, func() {
+			deleteMappingKey(e.Item, "tags")
+			if len(e.Item.Content) == 2 && e.PathN != nil {
+				// Only the path is left: collapse to the short form
+				pathN := *e.PathN
+				pathN.HeadComment, pathN.LineComment, pathN.FootComment = e.Item.HeadComment, e.Item.LineComment, e.Item.FootComment
+				*e.Item = pathN
+			}
+		}, "tags %v equal the inherited %s tags; remove them", e.Inherited, e.scope())
+	}
+
+	for _, section := range []string{"all", "linux", "macos"} {
+		tagsN := mappingValue(mappingValue(root, section), "tags")
+		platformTags := scalarValues(tagsN)
+		if len(platformTags) == 0 {
+			continue
+		}
+		inheriting := 0
+		for _, e := range entries {
+			if e.Section == section && e.TagsN == nil && e.FromSection && !duplicates[e.Item] {
+				inheriting++
+			}
+		}
+		if inheriting == 0 {
+			add(tagsN, nil, "%s tags %v are never applied: no path in %s inherits them", section, platformTags, section)
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues
+}
+
+// encodeConfigNode renders a document back to YAML with the indentation used
+// throughout the examples.
+func encodeConfigNode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var lintFix bool
+
+var configLintCmd = &cobra.Command{
+	Use:         "lint",
+	Short:       "Report style and correctness warnings in the config",
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		osName, _ := getOSName()
+		remaining, err := runConfigLint(filepath.SplitList(getConfigPath()), osName, lintFix)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if remaining > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// runConfigLint lints each file, applying fixes when fix is set, and returns
+// the number of warnings left unfixed.
+func runConfigLint(files []string, platform string, fix bool) (int, error) {
+	remaining := 0
+	for _, file := range files {
+		if _, err := loadConfig(file); err != nil {
+			return 0, err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return 0, fmt.Errorf("error reading config file: %v", err)
+		}
+		doc, err := parseConfigDocument(file, data)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing config file: %v", err)
+		}
+
+		issues := lintConfigNode(doc, platform)
+		fixed := 0
+		for _, issue := range issues {
+			status := ""
+			switch {
+			case issue.Fix != nil && fix:
+				issue.Fix()
+				fixed++
+				status = " [fixed]"
+			case issue.Fix != nil:
+				status = " [fixable]"
+			}
+			if status != " [fixed]" {
+				remaining++
+			}
+			if issue.Line == 0 {
+				fmt.Printf("%s: warning: %s%s\n", file, issue.Message, status)
+			} else {
+				fmt.Printf("%s:%d:%d: warning: %s%s\n", file, issue.Line, issue.Column, issue.Message, status)
+			}
+		}
+
+		if fixed > 0 {
+			out, err := encodeConfigDocument(doc, configFormat(file))
+			if err != nil {
+				return 0, err
+			}
+			if err := writeFileAtomic(file, out); err != nil {
+				return 0, err
+			}
+			fmt.Printf("Fixed %d issue(s) in %s\n", fixed, file)
+		}
+	}
+	if remaining == 0 {
+		fmt.Println("No lint warnings")
+	}
+	return remaining, nil
+}
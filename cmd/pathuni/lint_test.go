@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const lintTestConfig = `# Shared paths
+all:
+  tags: [base]
+  paths:
+    - /tmp/pathuni/usr/bin   # system
+    - /opt/homebrew/bin
+    - /home/alice/.local/bin
+    - path: /tmp/pathuni/usr/local/bin
+      tags: [base]
+    - path: $PATHUNI_LINT_UNSET/bin
+      tags: [dev]
+linux:
+  tags: [penguin]
+  paths:
+    - /tmp/pathuni/usr/bin
+    - path: /tmp/pathuni/nowhere
+      tags: [x11]
+`
+
+func lintMessages(issues []lintIssue) []string {
+	var out []string
+	for _, i := range issues {
+		out = append(out, i.Message)
+	}
+	return out
+}
+
+func TestLint_Warnings(t *testing.T) {
+	setupTestFilesystem(t)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(lintTestConfig), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	issues := lintConfigNode(&doc, "Linux")
+	got := strings.Join(lintMessages(issues), "\n")
+
+	for _, want := range []string{
+		`path "/opt/homebrew/bin" is macos-specific; move it to the macos section`,
+		`hard-coded home directory in "/home/alice/.local/bin"; use $HOME`,
+		`tags [base] equal the inherited all tags; remove them`,
+		`path "$PATHUNI_LINT_UNSET/bin" references unset variable $PATHUNI_LINT_UNSET`,
+		`duplicate path "/tmp/pathuni/usr/bin" (already in all at line 5)`,
+		`linux tags [penguin] are never applied: no path in linux inherits them`,
+		`path "/tmp/pathuni/nowhere" does not exist on this machine`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing warning %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `"/opt/homebrew/bin" does not exist`) {
+		t.Errorf("OS-specific entries should not also be reported as missing:\n%s", got)
+	}
+	if issues[0].Line != 6 || issues[0].Column != 7 {
+		t.Errorf("expected first issue at 6:7, got %d:%d", issues[0].Line, issues[0].Column)
+	}
+
+	// The same paths on macOS: linux entries are not checked for existence
+	if strings.Contains(strings.Join(lintMessages(lintConfigNode(&doc, "macOS")), "\n"), "nowhere") {
+		t.Error("linux section should not be checked for existence on macOS")
+	}
+}
+
+func TestLint_FixRewritesPreservingComments(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "my_paths.yaml")
+	writeFile(t, cfgPath, lintTestConfig)
+
+	var remaining int
+	var err error
+	out := captureOutput(func() { remaining, err = runConfigLint([]string{cfgPath}, "Linux", true) })
+	if err != nil {
+		t.Fatalf("runConfigLint: %v", err)
+	}
+	if !strings.Contains(out, "Fixed 4 issue(s) in "+cfgPath) || remaining != 4 {
+		t.Errorf("unexpected lint output (remaining=%d):\n%s", remaining, out)
+	}
+
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := `# Shared paths
+all:
+  tags: [base]
+  paths:
+    - /tmp/pathuni/usr/bin # system
+    - $HOME/.local/bin
+    - /tmp/pathuni/usr/local/bin
+    - path: $PATHUNI_LINT_UNSET/bin
+      tags: [dev]
+linux:
+  tags: [penguin]
+  paths:
+    - path: /tmp/pathuni/nowhere
+      tags: [x11]
+macos:
+  paths:
+    - path: /opt/homebrew/bin
+      tags: [base]
+`
+	if string(data) != want {
+		t.Errorf("fixed config mismatch:\n got:\n%s\nwant:\n%s", data, want)
+	}
+	if _, err := loadConfig(cfgPath); err != nil {
+		t.Errorf("fixed config no longer loads: %v", err)
+	}
+
+	// Fixing again finds nothing left to fix
+	out = captureOutput(func() { _, _ = runConfigLint([]string{cfgPath}, "Linux", true) })
+	if strings.Contains(out, "Fixed") || strings.Contains(out, "[fixable]") {
+		t.Errorf("second fix pass should be a no-op:\n%s", out)
+	}
+}
+
+func TestLint_ShellPathsAndEmptyTags(t *testing.T) {
+	setupTestFilesystem(t)
+
+	const cfg = `all:
+  paths:
+    - /tmp/pathuni/usr/bin
+    - path: /tmp/pathuni/usr/local/bin
+      tags: []
+    - /opt/homebrew/bin
+  shells:
+    zsh:
+      paths:
+        - /tmp/pathuni/usr/bin
+        - /home/alice/.zsh/bin
+macos:
+  tags: [mac]
+`
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(cfg), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	issues := lintConfigNode(&doc, "Linux")
+	got := strings.Join(lintMessages(issues), "\n")
+	for _, want := range []string{
+		`tags [] equal the inherited all tags; remove them`,
+		`duplicate path "/tmp/pathuni/usr/bin" (already in all at line 3)`,
+		`hard-coded home directory in "/home/alice/.zsh/bin"; use $HOME`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing warning %q in:\n%s", want, got)
+		}
+	}
+
+	// An untagged entry cannot move into a tagged section unchanged
+	for _, issue := range issues {
+		if strings.Contains(issue.Message, "macos-specific") && issue.Fix != nil {
+			t.Errorf("moving an untagged entry into tagged macos should not be fixable")
+		}
+	}
+}
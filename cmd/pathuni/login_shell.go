@@ -0,0 +1,172 @@
+package main
+
+// Login-shell PATH capture. The system side of the PATH is normally whatever
+// PATH pathuni inherited, which differs between a login terminal, a cron job
+// and a GUI launcher. With `system_source: login_shell` it is instead the
+// PATH a fresh login shell ends up with, captured by running
+// `$SHELL -lic 'printf %s "$PATH"'` in a clean environment.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// loginShellTimeout bounds how long the login shell may take to start.
+var loginShellTimeout = 5 * time.Second
+
+// loginShellCacheBucket is the cache bucket holding captured PATHs.
+const loginShellCacheBucket = "login_shell"
+
+// loginShellSentinel is set in the environment of the captured shell. A
+// pathuni started from its rc files sees it and uses the inherited PATH
+// instead of capturing again, which would recurse forever.
+const loginShellSentinel = "PATHUNI_LOGIN_SHELL_CAPTURE"
+
+// loginShellCaptures memoizes captures within one run, so the shell runs
+// and a failure is reported at most once.
+var loginShellCaptures = map[string]loginShellCapture{}
+
+type loginShellCapture struct {
+	Path string
+	Err  error
+}
+
+// loginShellEnv lists the variables passed through to the captured shell.
+var loginShellEnv = []string{"HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "TMPDIR"}
+
+// loginShellStartupFiles are the files whose modification invalidates a
+// cached capture, relative to $HOME unless absolute.
+var loginShellStartupFiles = []string{
+	"/etc/profile", "/etc/environment", "/etc/paths", "/etc/zprofile", "/etc/zshenv", "/etc/bash.bashrc",
+	".profile", ".bash_profile", ".bash_login", ".bashrc", ".zshenv", ".zprofile", ".zshrc", ".zlogin",
+	".config/fish/config.fish",
+}
+
+func isValidSystemSource(source string) bool {
+	return source == "" || source == "env" || source == "login_shell"
+}
+
+// systemSourceFor returns the system source configured for platform,
+// falling back to the all section.
+func systemSourceFor(cfg *Config, platform string) string {
+	var source string
+	switch platform {
+	case "macOS":
+		source = cfg.MacOS.SystemSource
+	case "Linux":
+		source = cfg.Linux.SystemSource
+	}
+	if source == "" {
+		source = cfg.All.SystemSource
+	}
+	return source
+}
+
+// baseSystemPath returns the PATH the system side starts from: the
+// inherited PATH, or the login shell's when configured. A failed capture
+// falls back to the inherited PATH with a warning on stderr.
+func baseSystemPath(cfg *Config, platform string) ([]string, error) {
+	if cfg == nil || systemSourceFor(cfg, platform) != "login_shell" || os.Getenv(loginShellSentinel) != "" {
+		return getCurrentPath()
+	}
+	sh := loginShell()
+	c, ok := loginShellCaptures[sh]
+	if !ok {
+		c.Path, c.Err = captureLoginShellPath(sh)
+		loginShellCaptures[sh] = c
+	}
+	captured, err := c.Path, c.Err
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pathuni: login shell PATH capture failed: %v; using the inherited PATH\n", err)
+		return getCurrentPath()
+	}
+	if captured == "" {
+		return []string{}, nil
+	}
+	return strings.Split(captured, ":"), nil
+}
+
+// loginShell returns the shell to capture the PATH from.
+func loginShell() string {
+	if sh := os.Getenv("SHELL"); filepath.IsAbs(sh) {
+		return sh
+	}
+	return "/bin/sh"
+}
+
+// loginShellFingerprint identifies the shell binary and the state of its
+// startup files, so editing an rc file invalidates the cache.
+func loginShellFingerprint(sh string) (string, error) {
+	info, err := os.Stat(sh)
+	if err != nil {
+		return "", err
+	}
+	parts := []string{fmt.Sprintf("%s@%d", sh, info.ModTime().UnixNano())}
+	home := homeDir()
+	for _, f := range loginShellStartupFiles {
+		if !filepath.IsAbs(f) {
+			if home == "" {
+				continue
+			}
+			f = filepath.Join(home, f)
+		}
+		if info, err := os.Stat(f); err == nil {
+			parts = append(parts, fmt.Sprintf("%s@%d", f, info.ModTime().UnixNano()))
+		}
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// captureLoginShellPath runs the login shell and returns its PATH. Results
+// are cached until the shell or one of its startup files changes.
+func captureLoginShellPath(sh string) (string, error) {
+	fingerprint, err := loginShellFingerprint(sh)
+	if err != nil {
+		return "", fmt.Errorf("shell %s: %v", sh, err)
+	}
+	if out, ok := cacheGet(loginShellCacheBucket, sh, fingerprint); ok {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), loginShellTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, sh, "-lic", `printf %s "$PATH"`)
+	env := []string{loginShellSentinel + "=1"}
+	for _, name := range loginShellEnv {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	cmd.Env = env
+	// Children started by rc files may hold stdout open past the timeout
+	cmd.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %s", sh, loginShellTimeout)
+		}
+		if excerpt := stderrExcerpt(stderr.Bytes()); excerpt != "" {
+			return "", fmt.Errorf("%s: %v: %s", sh, err, excerpt)
+		}
+		return "", fmt.Errorf("%s: %v", sh, err)
+	}
+
+	// rc files may print to stdout; the PATH is the last line
+	out := strings.TrimSpace(stdout.String())
+	if i := strings.LastIndexByte(out, '\n'); i >= 0 {
+		out = out[i+1:]
+	}
+	if out == "" {
+		return "", fmt.Errorf("%s printed no PATH", sh)
+	}
+	cachePut(loginShellCacheBucket, sh, fingerprint, out)
+	return out, nil
+}
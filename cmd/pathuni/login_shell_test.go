@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeLoginShell writes a shell script standing in for $SHELL. It runs the
+// command it is given after setting PATH the way a login shell would.
+func fakeLoginShell(t *testing.T, body string) string {
+	t.Helper()
+	sh := filepath.Join(t.TempDir(), "fakesh")
+	script := "#!/bin/sh\n[ \"$1\" = -lic ] || exit 2\n" + body + "\neval \"$2\"\n"
+	if err := os.WriteFile(sh, []byte(script), 0755); err != nil {
+		t.Fatalf("write fake shell: %v", err)
+	}
+	t.Setenv("SHELL", sh)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	loginShellCaptures = map[string]loginShellCapture{}
+	t.Cleanup(func() { loginShellCaptures = map[string]loginShellCapture{} })
+	return sh
+}
+
+func loginShellConfig(t *testing.T) string {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "login.yaml")
+	writeFile(t, cfgPath, "all:\n  system_source: login_shell\n")
+	return cfgPath
+}
+
+func TestLoginShell_CapturesPath(t *testing.T) {
+	t.Setenv("PATHUNI_LEAK", "leaked")
+	t.Setenv("PATH", "/inherited/bin:/usr/bin:/bin")
+	fakeLoginShell(t, `echo "Welcome"
+PATH="/login/bin:/usr/bin${PATHUNI_LEAK:+:/$PATHUNI_LEAK}${PATHUNI_LOGIN_SHELL_CAPTURE:+:/sentinel}"`)
+
+	sys, err := resolveSystemPathsContext(loginShellConfig(t), "Linux", "bash")
+	if err != nil {
+		t.Fatalf("resolveSystemPathsContext: %v", err)
+	}
+	if got, want := strings.Join(sys, ":"), "/login/bin:/usr/bin:/sentinel"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Without system_source the inherited PATH is used
+	cfgPath := filepath.Join(t.TempDir(), "env.yaml")
+	writeFile(t, cfgPath, "all:\n  paths: []\n")
+	sys, _ = resolveSystemPathsContext(cfgPath, "Linux", "bash")
+	if got := strings.Join(sys, ":"); got != "/inherited/bin:/usr/bin:/bin" {
+		t.Errorf("env source: got %s", got)
+	}
+}
+
+func TestLoginShell_Cached(t *testing.T) {
+	sh := fakeLoginShell(t, `PATH=/first/bin`)
+	if got, err := captureLoginShellPath(sh); err != nil || got != "/first/bin" {
+		t.Fatalf("first capture = %q, %v", got, err)
+	}
+
+	// Rewriting the script with the same mtime still hits the cache
+	info, _ := os.Stat(sh)
+	if err := os.WriteFile(sh, []byte("#!/bin/sh\nprintf %s /second/bin\n"), 0755); err != nil {
+		t.Fatalf("rewrite: %v", err)
+	}
+	os.Chtimes(sh, info.ModTime(), info.ModTime())
+	if got, _ := captureLoginShellPath(sh); got != "/first/bin" {
+		t.Errorf("expected cached capture, got %q", got)
+	}
+
+	// Touching a startup file invalidates it
+	profile := filepath.Join(os.Getenv("HOME"), ".profile")
+	writeFile(t, profile, "# edited\n")
+	if got, _ := captureLoginShellPath(sh); got != "/second/bin" {
+		t.Errorf("expected fresh capture after editing .profile, got %q", got)
+	}
+}
+
+func TestLoginShell_RecursionGuard(t *testing.T) {
+	t.Setenv("PATH", "/inherited/bin")
+	fakeLoginShell(t, "exit 1")
+	t.Setenv(loginShellSentinel, "1")
+
+	sys, err := resolveSystemPathsContext(loginShellConfig(t), "Linux", "bash")
+	if err != nil || strings.Join(sys, ":") != "/inherited/bin" {
+		t.Errorf("inside a capture the inherited PATH must be used, got %v, %v", sys, err)
+	}
+}
+
+func TestLoginShell_Timeout(t *testing.T) {
+	t.Setenv("PATH", "/inherited/bin:/usr/bin:/bin")
+	sh := fakeLoginShell(t, "sleep 5")
+	old := loginShellTimeout
+	loginShellTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { loginShellTimeout = old })
+
+	if _, err := captureLoginShellPath(sh); err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	sys, _ := resolveSystemPathsContext(loginShellConfig(t), "Linux", "bash")
+	if strings.Join(sys, ":") != "/inherited/bin:/usr/bin:/bin" {
+		t.Errorf("a failed capture should fall back to the inherited PATH, got %v", sys)
+	}
+}
+
+func TestLoginShell_InvalidSource(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "login.yaml")
+	writeFile(t, cfgPath, "linux:\n  system_source: cron\n")
+	if _, err := loadConfig(cfgPath); err == nil || !strings.Contains(err.Error(), "invalid system_source 'cron' in linux") {
+		t.Errorf("expected invalid system_source error, got %v", err)
+	}
+}
@@ -28,8 +28,7 @@ func getConfigPath() string {
 	if config != "" {
 		return config
 	}
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "pathuni", "my_paths.yaml")
+	return discoveredConfigPath(discoverConfigFiles())
 }
 
 func getOSName() (string, bool) {
@@ -76,6 +75,8 @@ func normalizeShellName(shell string) string {
 	switch shell {
 	case "pwsh":
 		return "powershell"
+	case "nushell":
+		return "nu"
 	default:
 		return shell
 	}
@@ -130,8 +131,26 @@ var rootCmd = &cobra.Command{
 	Long: `pathuni - Cross-platform PATH management for dotfiles
 
 Generate shell-specific PATH export commands from a YAML config file.
-Validates that directories exist before including them.`,
+Validates that directories exist before including them.
+
+Every global flag can also be set through a PATHUNI_* environment variable
+(e.g. PATHUNI_TAGS_INCLUDE for --tags-include). Precedence is flag, then
+environment, then the active profile and config defaults, then built-in.`,
 	Version: Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Flags are parsed by now; errors from here on are not usage errors
+		// and main reports them itself
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		// Layer environment, profile and config defaults under passed flags
+		if err := resolveSettings(cmd); err != nil {
+			return err
+		}
+		if err := validateRoot(); err != nil {
+			return err
+		}
+		return validateStatTimeout()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Default to init command
 		runInit()
@@ -142,26 +161,81 @@ func init() {
 	// Add persistent flags (available to all commands)
 	rootCmd.PersistentFlags().StringVarP(&shell, "shell", "S", "", "Shell type: sh|ash|bash|dash|ksh|mksh|yash|zsh|fish|powershell (detected if not specified)")
 	// If building for Windows in the future, will need to be something like %USERPROFILE%\AppData\Local\pathuni\my_paths.yaml
-	rootCmd.PersistentFlags().StringVarP(&config, "config", "c", "", "Path to config file (default: ~/.config/pathuni/my_paths.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&config, "config", "c", "", "Config file, or files separated by ':' (default: discovered under XDG dirs and .pathuni.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&osOverride, "os", "O", "", "OS type: macOS|linux (detected if not specified)")
 	rootCmd.PersistentFlags().StringVarP(&tagsInclude, "tags-include", "t", "", "Include paths with tags (comma=OR, plus=AND): home,dev or work+server")
     rootCmd.PersistentFlags().StringVarP(&tagsExclude, "tags-exclude", "x", "", "Exclude paths with tags (comma=OR, plus=AND): gaming,temp or work+gaming")
     // Global scope flag used by all commands (init, dry-run, dump)
     rootCmd.PersistentFlags().StringVarP(&scope, "scope", "s", "full", "Paths to include: system|pathuni|full")
+    // Named profile bundling tag filters and flags (also PATHUNI_PROFILE)
+    rootCmd.PersistentFlags().StringVarP(&profileName, "profile", "P", "", "Profile from the config to apply (default: $PATHUNI_PROFILE)")
 
     // Add subcommands
     rootCmd.AddCommand(initCmd)
     rootCmd.AddCommand(dryRunCmd)
     rootCmd.AddCommand(dumpCmd)
+    rootCmd.AddCommand(profilesCmd)
+    rootCmd.AddCommand(configCmd)
+    configCmd.AddCommand(configShowCmd)
+    configCmd.AddCommand(configLintCmd)
+    configCmd.AddCommand(configSchemaCmd)
+    configCmd.AddCommand(configConvertCmd)
+    rootCmd.AddCommand(hookCmd)
+    rootCmd.AddCommand(hookEnvCmd)
+    rootCmd.AddCommand(allowCmd)
+    rootCmd.AddCommand(unloadCmd)
+    rootCmd.AddCommand(exportCmd)
+    rootCmd.AddCommand(snapshotCmd)
+    snapshotCmd.AddCommand(snapshotSaveCmd)
+    snapshotCmd.AddCommand(snapshotListCmd)
+    snapshotCmd.AddCommand(snapshotShowCmd)
+    snapshotCmd.AddCommand(snapshotDiffCmd)
+    snapshotCmd.AddCommand(snapshotRestoreCmd)
 
 
     // Add flags specific to dump command
     dumpCmd.Flags().StringVarP(&dumpFormat, "format", "f", "plain", "Output format: plain|json|yaml")
 
+    // Add flags specific to config lint command
+    configLintCmd.Flags().BoolVar(&lintFix, "fix", false, "Rewrite the config applying fixable warnings")
+
+    // Add flags specific to config convert command
+    configConvertCmd.Flags().StringVar(&convertTo, "to", "yaml", "Output format: yaml|toml|json")
+
+    // Add flags specific to snapshot commands
+    snapshotDiffCmd.Flags().BoolVar(&snapshotLive, "live", false, "Compare the live PATH instead of the computed one")
+    snapshotRestoreCmd.Flags().BoolVar(&snapshotLive, "live", false, "Restore the live PATH instead of the computed one")
+
+    // Add flags specific to export command
+    exportCmd.Flags().StringVar(&exportTarget, "target", "", "Artifact to render: "+strings.Join(exportTargetNames(), "|"))
+    exportCmd.Flags().BoolVar(&exportWrite, "write", false, "Install the artifact, showing a diff against the existing file")
+    exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File written by --write (default: the target's standard location)")
+    exportCmd.Flags().BoolVar(&exportPruneMissing, "prune-missing", false, "Drop entries missing on this machine (github, dockerfile, dotenv and makefile targets)")
+    exportCmd.Flags().BoolVar(&exportFullPath, "full-path", false, "Render the fully expanded PATH, inherited entries included ("+strings.Join(fullPathTargets(), " and ")+" targets)")
+    exportCmd.MarkFlagRequired("target")
+
+    // Add flags specific to allow command
+    allowCmd.Flags().BoolVar(&allowRevoke, "revoke", false, "Remove the file from the allowlist instead")
+
     // Register defer-env at root so `pathuni -d` works (root defaults to init)
     rootCmd.PersistentFlags().BoolVarP(&deferEnv, "defer-env", "d", false, "Do not expand current PATH; reference it at evaluation time (init only, requires --scope=full)")
     // Prune flag (persistent) - controls removal of non-existent directories
     rootCmd.PersistentFlags().StringVarP(&prune, "prune", "p", "pathuni", "Prune missing paths: none|pathuni|system|all")
+    // With-revert flag (persistent so the root default init sees it)
+    rootCmd.PersistentFlags().BoolVar(&withRevert, "with-revert", false, "Also export PATHUNI_ADDED and PATHUNI_ORIGINAL_PATH for 'pathuni unload' (init only)")
+    // Auto-snapshot flag (persistent so the root default init sees it)
+    rootCmd.PersistentFlags().BoolVar(&autoSnapshot, "auto-snapshot", false, "Save a snapshot from init when the computed PATH changes")
+    // Strict flag (persistent) - missing required entries become an error
+    rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, fmt.Sprintf("Exit with code %d when a required path is missing", exitRequiredMissing))
+    // Dedupe flag (persistent) - controls when two entries count as the same directory
+    rootCmd.PersistentFlags().StringVar(&dedupe, "dedupe", "clean", "Duplicate detection: exact|clean|realpath")
+    // Root flag (persistent) - where existence checks and system file reads happen
+    rootCmd.PersistentFlags().StringVar(&rootDir, "root", "", "Check paths and read system files under this directory, e.g. a mounted image")
+    // Stat timeout flags (persistent) - deadline for existence checks on slow mounts
+    rootCmd.PersistentFlags().DurationVar(&statTimeout, "stat-timeout", 0, "Give up checking a path after this long, e.g. 200ms (0: wait)")
+    rootCmd.PersistentFlags().StringVar(&statTimeoutPolicy, "stat-timeout-policy", "skip", "Paths whose check timed out: include|skip")
+    // Accept unknown config keys instead of failing on them
+    rootCmd.PersistentFlags().BoolVar(&lenient, "lenient", false, "Ignore unknown keys in the config instead of reporting them")
 
 	// Custom version template
 	rootCmd.SetVersionTemplate(`pathuni version ` + Version + `
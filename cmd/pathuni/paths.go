@@ -3,39 +3,28 @@ package main
 // Shared path resolution helpers to avoid duplication across commands.
 
 import (
-    "os"
-    "gopkg.in/yaml.v3"
 )
 
-// dedupePreserveOrder removes duplicate strings while preserving the first
-// occurrence order.
+// dedupePreserveOrder removes duplicate paths (per the --dedupe mode) while
+// preserving the first occurrence and its spelling.
 func dedupePreserveOrder(in []string) []string {
     seen := make(map[string]struct{}, len(in))
     out := make([]string, 0, len(in))
     for _, s := range in {
-        if _, ok := seen[s]; ok {
+        key := dedupeKey(s)
+        if _, ok := seen[key]; ok {
             continue
         }
-        seen[s] = struct{}{}
+        seen[key] = struct{}{}
         out = append(out, s)
     }
     return out
 }
 
-// resolveSystemPaths returns the current PATH entries, deduped.
-func resolveSystemPaths() ([]string, error) {
-    paths, err := getCurrentPath()
-    if err != nil {
-        return nil, err
-    }
-    return dedupePreserveOrder(paths), nil
-}
-
-// resolvePathuniPaths returns config-derived paths for the current context,
-// respecting the global prune flag. When prune is "pathuni" or "all", only
-// existing paths are included (current behavior). When prune is "none" or
-// "system", include paths that pass tag filtering regardless of existence.
-func resolvePathuniPaths() ([]string, error) {
+// evaluatePathuni evaluates the config for the current context. Commands and
+// existence checks run here, so commands evaluate it once and derive both the
+// placed entries and the required-entry checks from the statuses.
+func evaluatePathuni() ([]PathStatus, error) {
     configPath := getConfigPath()
     osName, _ := getOSName()
     shellName, _ := getShellName()
@@ -43,27 +32,15 @@ func resolvePathuniPaths() ([]string, error) {
     if err != nil { return nil, err }
 
     statuses, _, err := EvaluateConfigDetailed(configPath, osName, shellName, tagFilter)
-    if err != nil { return nil, err }
+    return statuses, err
+}
 
-    var out []string
-    switch prune {
-    case "pathuni", "all":
-        for _, st := range statuses {
-            if st.Included {
-                out = append(out, st.Path)
-            }
-        }
-    case "none", "system":
-        for _, st := range statuses {
-            if st.PassesFilter { // include even if not existing
-                out = append(out, st.Path)
-            }
-        }
-    default:
-        // Fallback to safe behavior
-        for _, st := range statuses { if st.Included { out = append(out, st.Path) } }
-    }
-    return dedupePreserveOrder(out), nil
+// prunePathuni reports whether the prune flag drops missing pathuni entries.
+// When prune is "pathuni" or "all", only existing paths are included. When
+// prune is "none" or "system", paths that pass tag filtering are included
+// regardless of existence.
+func prunePathuni() bool {
+    return prune != "none" && prune != "system"
 }
 
 // mergeFull merges pathuni and system lists according to the precedence flag
@@ -83,87 +60,74 @@ func mergeFull(pathuni, system []string, pathuniFirst bool) []string {
     return dedupePreserveOrder(combined)
 }
 
-// filterExisting returns only entries that exist and are directories.
+// filterExisting returns only entries that exist and are directories,
+// checking them concurrently (see stat.go). Entries referencing an unset
+// variable do not exist.
 func filterExisting(paths []string) []string {
-    out := make([]string, 0, len(paths))
+    expanded := make([]string, 0, len(paths))
     for _, p := range paths {
-        expanded := os.ExpandEnv(p)
-        if info, err := os.Stat(expanded); err == nil && info.IsDir() {
-            out = append(out, expanded)
+        if e, err := expandPath(p, nil); err == nil {
+            expanded = append(expanded, e)
+        }
+    }
+    out := make([]string, 0, len(paths))
+    for i, st := range statPaths(expanded) {
+        if st.isDir() {
+            out = append(out, expanded[i])
         }
     }
     return out
 }
 
-// getPowerShellPathEntries returns system path files as PathEntry(ies) when
-// configured to be injected on the pathuni side (as=pathuni). When tags are
-// provided in the YAML, they are attached explicitly; otherwise Tags=nil so
-// platform tag inheritance applies.
-func getPowerShellPathEntries(shell string, platformConfig PlatformConfig) []PathEntry {
-    var entries []PathEntry
-    if shell != "powershell" || platformConfig.PowerShell == nil || !platformConfig.PowerShell.IncludeSystemPaths {
-        return entries
-    }
-    as := platformConfig.PowerShell.IncludeSystemPathsAs
-    if as == "" { as = "system" }
-    if as != "pathuni" {
-        return entries
-    }
-    sys, err := getSystemPaths()
-    if err != nil {
-        return entries
-    }
-    var tags []string
-    if platformConfig.PowerShell.Tags != nil {
-        // Explicit tags provided (possibly empty slice to break inheritance)
-        tags = append([]string{}, platformConfig.PowerShell.Tags...)
-    } else {
-        // nil indicates inheritance
-        tags = nil
-    }
-    for _, p := range sys {
-        entries = append(entries, PathEntry{Path: p, Tags: tags})
-    }
-    return entries
+// dirExists reports whether path exists and is a directory.
+func dirExists(path string) bool {
+    return statWithTimeout(path).isDir()
 }
 
 // resolveSystemPathsContext returns system paths considering config context.
-// When shell=powershell and the platform config has include_system_paths true
-// with classification as "system" (default), also include macOS system path
-// files from /etc/paths and /etc/paths.d/*.
+// When the shell's config has include_system_paths true with classification
+// as "system" (default), also include the configured
+// system sources (see system_sources.go). Entries matching the config's
+// remove patterns are dropped.
 func resolveSystemPathsContext(configPath, platform, shell string) ([]string, error) {
-    // Start with current PATH entries (deduped)
-    sys, err := getCurrentPath()
+    sys, _, err := resolveSystemPathsDetailed(configPath, platform, shell)
+    return sys, err
+}
+
+// resolveSystemPathsDetailed is resolveSystemPathsContext that also reports
+// the entries dropped by remove patterns, for dry-run output.
+func resolveSystemPathsDetailed(configPath, platform, shell string) ([]string, []removedPath, error) {
+    // Start with the inherited (or login shell) PATH entries (deduped)
+    cfg, cfgErr := readConfigFiles(configPath)
+    if cfgErr != nil {
+        cfg = nil
+    }
+    sys, err := baseSystemPath(cfg, platform)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
     sys = dedupePreserveOrder(sys)
+    if cfg == nil {
+        return sys, nil, nil
+    }
 
-    // Only enhance for PowerShell when configured
-    if shell == "powershell" {
-        data, err := os.ReadFile(configPath)
-        if err == nil {
-            var cfg Config
-            if yaml.Unmarshal(data, &cfg) == nil {
-                var p PlatformConfig
-                switch platform {
-                case "macOS":
-                    p = cfg.MacOS
-                case "Linux":
-                    p = cfg.Linux
-                }
-                if p.PowerShell != nil && p.PowerShell.IncludeSystemPaths {
-                    as := p.PowerShell.IncludeSystemPathsAs
-                    if as == "" { as = "system" }
-                    if as == "system" {
-                        if extra, err := getSystemPaths(); err == nil {
-                            sys = mergeFull([]string{}, append(sys, extra...), false) // system-first irrelevant; we just dedupe
-                        }
-                    }
-                }
+    // Add system sources for shells configured to include them as system
+    platforms := []PlatformConfig{cfg.All}
+    switch platform {
+    case "macOS":
+        platforms = append(platforms, cfg.MacOS)
+    case "Linux":
+        platforms = append(platforms, cfg.Linux)
+    }
+    for _, p := range platforms {
+        sc := shellConfigFor(p, shell)
+        if systemPathsAs(sc) == "system" {
+            if extra, err := getSystemPathsFrom(sc.SystemSources); err == nil {
+                sys = mergeFull([]string{}, append(sys, extra...), false) // system-first irrelevant; we just dedupe
             }
         }
     }
 
-    return dedupePreserveOrder(sys), nil
+    kept, removed := applyRemovePatterns(dedupePreserveOrder(sys), removePatterns(cfg, platform), cfg.Vars)
+    return kept, removed, nil
 }
@@ -8,7 +8,6 @@ import (
 // TestPlatformTags_BasicInheritance tests basic platform-level tag inheritance scenarios
 func TestPlatformTags_BasicInheritance(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 
 	testConfigPath := filepath.Join("testdata", "platform_tags_basic.yaml")
 
@@ -82,7 +81,6 @@ func TestPlatformTags_BasicInheritance(t *testing.T) {
 // TestPlatformTags_BackwardsCompatibility tests that existing configs without platform tags still work
 func TestPlatformTags_BackwardsCompatibility(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 
 	testConfigPath := filepath.Join("testdata", "platform_tags_backwards_compat.yaml")
 
@@ -117,7 +115,6 @@ func TestPlatformTags_BackwardsCompatibility(t *testing.T) {
 // TestPlatformTags_ComplexScenarios tests complex inheritance and filtering scenarios
 func TestPlatformTags_ComplexScenarios(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 
 	testConfigPath := filepath.Join("testdata", "platform_tags_complex.yaml")
 
@@ -197,7 +194,6 @@ func TestPlatformTags_ComplexScenarios(t *testing.T) {
 // TestPlatformTags_WildcardFiltering tests wildcard pattern matching in tag filtering
 func TestPlatformTags_WildcardFiltering(t *testing.T) {
 	setupTestFilesystem(t)
-	defer cleanupTestFilesystem()
 
 	testConfigPath := filepath.Join("testdata", "wildcard_tags_basic.yaml")
 
@@ -0,0 +1,200 @@
+package main
+
+// Position control for pathuni entries: by default entries are prepended to the
+// system PATH, but an entry can ask to be appended or anchored right before or
+// after a given system entry. A numeric priority orders entries that share a
+// group (higher first).
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// PathPosition describes where a pathuni entry goes relative to system entries.
+type PathPosition struct {
+	Mode   string // "prepend" (default), "append", "before" or "after"
+	Anchor string // System entry used by "before" and "after"
+}
+
+// String renders the position the way it is written in the config.
+func (p PathPosition) String() string {
+	switch p.Mode {
+	case "before", "after":
+		return fmt.Sprintf("%s %s", p.Mode, p.Anchor)
+	case "":
+		return "prepend"
+	default:
+		return p.Mode
+	}
+}
+
+// placedPath is a resolved pathuni path along with its placement settings.
+type placedPath struct {
+	Path     string
+	Position PathPosition
+	Priority int
+	Provider string
+}
+
+// extractPosition reads the optional 'position' field of a path object, which
+// is either a scalar ("prepend" or "append") or a single-key mapping
+// ({before: /usr/bin} or {after: /usr/local/bin}).
+func extractPosition(v map[string]interface{}, context string, i int) (PathPosition, error) {
+	raw, ok := v["position"]
+	if !ok {
+		return PathPosition{}, nil
+	}
+	switch p := raw.(type) {
+	case string:
+		switch p {
+		case "prepend", "append":
+			return PathPosition{Mode: p}, nil
+		}
+	case map[string]interface{}:
+		if len(p) == 1 {
+			for mode, anchor := range p {
+				anchorStr, isString := anchor.(string)
+				if (mode == "before" || mode == "after") && isString && anchorStr != "" {
+					return PathPosition{Mode: mode, Anchor: anchorStr}, nil
+				}
+			}
+		}
+	}
+	return PathPosition{}, fmt.Errorf("invalid position in %s at index %d: expected prepend, append, {before: <path>} or {after: <path>}", context, i)
+}
+
+// extractPriority reads the optional integer 'priority' field of a path object.
+func extractPriority(v map[string]interface{}, context string, i int) (int, error) {
+	raw, ok := v["priority"]
+	if !ok {
+		return 0, nil
+	}
+	priority, isInt := raw.(int)
+	if !isInt {
+		return 0, fmt.Errorf("invalid priority in %s at index %d: expected integer", context, i)
+	}
+	return priority, nil
+}
+
+// placedFromStatuses selects the pathuni entries that make it into the PATH.
+// When pruneMissing is true only existing entries are kept; otherwise every
+// entry passing tag filtering is kept regardless of existence.
+func placedFromStatuses(statuses []PathStatus, pruneMissing bool) []placedPath {
+	var out []placedPath
+	for _, st := range statuses {
+		if (pruneMissing && st.Included) || (!pruneMissing && st.PassesFilter) {
+			out = append(out, placedPath{Path: st.Path, Position: st.Position, Priority: st.Priority, Provider: st.Provider})
+		}
+	}
+	return out
+}
+
+// sortByPriority orders a group by descending priority, keeping config order
+// between entries of equal priority.
+func sortByPriority(group []placedPath) {
+	sort.SliceStable(group, func(i, j int) bool { return group[i].Priority > group[j].Priority })
+}
+
+// mergePlacedEntries merges pathuni and system entries honouring positions and
+// returns a deduped, order-preserving list labelled by origin. Anchored entries
+// whose anchor is not among the system entries fall back to the append group.
+func mergePlacedEntries(pathuni []placedPath, system []string) []includedEntry {
+	entries, _ := mergePlacedDetailed(pathuni, system)
+	return entries
+}
+
+// mergePlacedDetailed is mergePlacedEntries that also reports the entries
+// dropped as duplicates of an earlier one, for dry-run output.
+func mergePlacedDetailed(pathuni []placedPath, system []string) ([]includedEntry, []duplicatePath) {
+	sys := dedupePreserveOrder(system)
+	sysIndex := make(map[string]bool, len(sys))
+	for _, s := range sys {
+		sysIndex[filepath.Clean(s)] = true
+	}
+
+	var prepend, appendGroup []placedPath
+	before := make(map[string][]placedPath)
+	after := make(map[string][]placedPath)
+	for _, p := range pathuni {
+		switch p.Position.Mode {
+		case "append":
+			appendGroup = append(appendGroup, p)
+		case "before", "after":
+			anchor := filepath.Clean(p.Position.Anchor)
+			if !sysIndex[anchor] {
+				appendGroup = append(appendGroup, p)
+			} else if p.Position.Mode == "before" {
+				before[anchor] = append(before[anchor], p)
+			} else {
+				after[anchor] = append(after[anchor], p)
+			}
+		default:
+			prepend = append(prepend, p)
+		}
+	}
+
+	seen := make(map[string]int)
+	var out []includedEntry
+	var dups []duplicatePath
+	add := func(path, origin, provider string) {
+		key := dedupeKey(path)
+		if i, ok := seen[key]; ok {
+			dups = append(dups, duplicatePath{Path: path, Origin: origin, Of: out[i]})
+			return
+		}
+		seen[key] = len(out)
+		out = append(out, includedEntry{Path: path, Origin: origin, Provider: provider})
+	}
+	addGroup := func(group []placedPath) {
+		sortByPriority(group)
+		for _, p := range group {
+			add(p.Path, "pathuni", p.Provider)
+		}
+	}
+
+	addGroup(prepend)
+	for _, s := range sys {
+		key := filepath.Clean(s)
+		addGroup(before[key])
+		add(s, "system", "")
+		addGroup(after[key])
+	}
+	addGroup(appendGroup)
+	return out, dups
+}
+
+// mergePlaced is mergePlacedEntries without origin labels.
+func mergePlaced(pathuni []placedPath, system []string) []string {
+	entries := mergePlacedEntries(pathuni, system)
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Path)
+	}
+	return out
+}
+
+// splitDeferPlaced divides pathuni entries into the prefix and suffix emitted
+// around the live PATH by --defer-env. The live PATH is unknown when the code
+// is generated, so anchored entries degrade to their nearest side: "before"
+// joins the prefix and "after" joins the suffix.
+func splitDeferPlaced(pathuni []placedPath) (prefix, suffix []string) {
+	var pre, post []placedPath
+	for _, p := range pathuni {
+		switch p.Position.Mode {
+		case "append", "after":
+			post = append(post, p)
+		default:
+			pre = append(pre, p)
+		}
+	}
+	sortByPriority(pre)
+	sortByPriority(post)
+	for _, p := range pre {
+		prefix = append(prefix, p.Path)
+	}
+	for _, p := range post {
+		suffix = append(suffix, p.Path)
+	}
+	return dedupePreserveOrder(prefix), dedupePreserveOrder(suffix)
+}
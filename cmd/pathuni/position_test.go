@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPosition_ExtractPathEntries(t *testing.T) {
+	pathsYAML := `- path: "/a"
+- path: "/b"
+  position: append
+  priority: 5
+- path: "/c"
+  position:
+    before: /usr/bin
+- path: "/d"
+  position: {after: /usr/local/bin}
+`
+	var paths []interface{}
+	if err := yaml.Unmarshal([]byte(pathsYAML), &paths); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	entries, err := extractPathEntries(paths, "test")
+	if err != nil {
+		t.Fatalf("extractPathEntries: %v", err)
+	}
+	want := []PathPosition{{}, {Mode: "append"}, {Mode: "before", Anchor: "/usr/bin"}, {Mode: "after", Anchor: "/usr/local/bin"}}
+	for i, e := range entries {
+		if e.Position != want[i] {
+			t.Errorf("entry %d: position = %+v, want %+v", i, e.Position, want[i])
+		}
+	}
+	if entries[1].Priority != 5 {
+		t.Errorf("expected priority 5, got %d", entries[1].Priority)
+	}
+
+	for _, bad := range []string{
+		"- path: /x\n  position: middle\n",
+		"- path: /x\n  position: {around: /usr/bin}\n",
+		"- path: /x\n  position: {before: /usr/bin, after: /bin}\n",
+		"- path: /x\n  priority: high\n",
+	} {
+		var p []interface{}
+		if err := yaml.Unmarshal([]byte(bad), &p); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if _, err := extractPathEntries(p, "test"); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestPosition_MergePlaced(t *testing.T) {
+	pu := []placedPath{
+		{Path: "/low", Priority: 1},
+		{Path: "/high", Priority: 10},
+		{Path: "/fallback", Position: PathPosition{Mode: "append"}},
+		{Path: "/pre-usr-bin", Position: PathPosition{Mode: "before", Anchor: "/usr/bin/"}},
+		{Path: "/post-local", Position: PathPosition{Mode: "after", Anchor: "/usr/local/bin"}},
+		{Path: "/orphan", Position: PathPosition{Mode: "before", Anchor: "/not/in/path"}},
+	}
+	sys := []string{"/usr/local/bin", "/usr/bin", "/bin"}
+
+	got := mergePlaced(pu, sys)
+	want := []string{"/high", "/low", "/usr/local/bin", "/post-local", "/pre-usr-bin", "/usr/bin", "/bin", "/fallback", "/orphan"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergePlaced()\n got: %v\nwant: %v", got, want)
+	}
+
+	prefix, suffix := splitDeferPlaced(pu)
+	if !reflect.DeepEqual(prefix, []string{"/high", "/low", "/pre-usr-bin", "/orphan"}) {
+		t.Errorf("unexpected defer prefix: %v", prefix)
+	}
+	if !reflect.DeepEqual(suffix, []string{"/fallback", "/post-local"}) {
+		t.Errorf("unexpected defer suffix: %v", suffix)
+	}
+}
+
+func TestPosition_DeferRenderers(t *testing.T) {
+	plan := deferPlan{Prefix: []string{"/a"}, Suffix: []string{"/z"}}
+	tests := map[string]string{
+		"bash":       renderBashStrip([]string{"/a", "/z"}) + "\n" + `export PATH="/a:${PATH}:/z"`,
+		"fish":       `set -gx PATH (for __pathuni_e in $PATH; contains -- $__pathuni_e '/a' '/z'; or printf '%s\n' $__pathuni_e; end); set -e __pathuni_e; set -gx PATH /a $PATH /z`,
+		"powershell": "$env:PATH = (($env:PATH -split ':') | Where-Object { @('/a', '/z') -notcontains $_ }) -join ':'\n" + `$env:PATH = "/a:${env:PATH}:/z"`,
+	}
+	for shellName, want := range tests {
+		if got := renderersDefer[shellName](plan); got != want {
+			t.Errorf("%s defer render = %q, want %q", shellName, got, want)
+		}
+	}
+	if got := renderPwshDefer(deferPlan{Prefix: []string{"/a"}}); !strings.HasSuffix(got, "\n"+`$env:PATH = "/a:$env:PATH"`) {
+		t.Errorf("prefix-only pwsh render changed: %q", got)
+	}
+}
+
+func TestPosition_InitHonoursPositions(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "position.yaml")
+	cfgContent := `all:
+  paths:
+    - "/tmp/pathuni/usr/local/bin"
+    - path: "/tmp/pathuni/opt/tools"
+      position: append
+    - path: "/tmp/pathuni/opt/dev/bin"
+      position:
+        before: /tmp/pathuni/bin
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	config = cfgPath
+	osOverride = "Linux"
+	shell = "bash"
+	prune = "pathuni"
+	scope = "full"
+	deferEnv = false
+	tagsInclude, tagsExclude = "", ""
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/bin")
+
+	out := captureOutput(runInit)
+	want := `export PATH="/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/opt/dev/bin:/tmp/pathuni/bin:/tmp/pathuni/opt/tools"` + "\n"
+	if out != want {
+		t.Errorf("init full mismatch:\nwant: %q\n got: %q", want, out)
+	}
+
+	dumpFormat = "plain"
+	dumped := captureDumpOutput(runDump)
+	if strings.Join(strings.Fields(dumped), ":") != strings.TrimSuffix(strings.TrimPrefix(want, `export PATH="`), "\"\n") {
+		t.Errorf("dump order should match init, got:\n%s", dumped)
+	}
+
+	deferEnv = true
+	out = captureOutput(runInit)
+	deferEnv = false
+	want = renderBashStrip([]string{"/tmp/pathuni/usr/local/bin", "/tmp/pathuni/opt/dev/bin", "/tmp/pathuni/opt/tools"}) + "\n" +
+		`export PATH="/tmp/pathuni/usr/local/bin:/tmp/pathuni/opt/dev/bin:${PATH}:/tmp/pathuni/opt/tools"` + "\n"
+	if out != want {
+		t.Errorf("init defer mismatch:\nwant: %q\n got: %q", want, out)
+	}
+
+	dry := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "full") })
+	if !strings.Contains(dry, "  [.] /tmp/pathuni/usr/bin\n  [+] /tmp/pathuni/opt/dev/bin\n  [.] /tmp/pathuni/bin\n  [+] /tmp/pathuni/opt/tools\n") {
+		t.Errorf("dry-run should list entries in positioned order, got:\n%s", dry)
+	}
+}
+
+func TestPosition_AnchorExpansion(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "anchor.yaml")
+	writeFile(t, cfgPath, `vars:
+  SYS: /tmp/pathuni
+all:
+  paths:
+    - path: "/tmp/pathuni/opt/dev/bin"
+      position:
+        before: $SYS/bin
+    - path: "/tmp/pathuni/opt/tools"
+      position:
+        after: $PATHUNI_TEST_UNSET_ANCHOR/bin
+`)
+	config = cfgPath
+	osOverride = "Linux"
+	shell = "bash"
+	prune = "pathuni"
+	scope = "full"
+	deferEnv = false
+	tagsInclude, tagsExclude = "", ""
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/bin:/bin")
+
+	// The anchor from vars is found; the unset one is never treated as "/bin"
+	out := captureOutput(runInit)
+	want := `export PATH="/tmp/pathuni/usr/bin:/tmp/pathuni/opt/dev/bin:/tmp/pathuni/bin:/bin:/tmp/pathuni/opt/tools"` + "\n"
+	if out != want {
+		t.Errorf("init mismatch:\nwant: %q\n got: %q", want, out)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+// Named profiles bundle tag filters and flags under a single name, selected
+// with --profile or PATHUNI_PROFILE. A profile may extend another one; the
+// extending profile's settings win.
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Profile is a named set of defaults for the global flags.
+type Profile struct {
+	Extends     string `yaml:"extends,omitempty"`
+	TagsInclude string `yaml:"tags_include,omitempty"`
+	TagsExclude string `yaml:"tags_exclude,omitempty"`
+	Scope       string `yaml:"scope,omitempty"`
+	Prune       string `yaml:"prune,omitempty"`
+	Dedupe      string `yaml:"dedupe,omitempty"`
+	DeferEnv    *bool  `yaml:"defer_env,omitempty"`
+}
+
+var (
+	profileName string
+
+	// activeProfile and activeProfileSource describe the selected profile
+	// ("flag" or "env").
+	activeProfile       string
+	activeProfileSource string
+)
+
+// resolveProfile returns the named profile with its extends chain applied,
+// along with the chain of profile names from most to least specific.
+func resolveProfile(profiles map[string]Profile, name string) (Profile, []string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+	for current := name; current != ""; current = profiles[current].Extends {
+		if seen[current] {
+			return Profile{}, nil, fmt.Errorf("profile '%s' has an extends cycle: %s -> %s", name, strings.Join(chain, " -> "), current)
+		}
+		if _, ok := profiles[current]; !ok {
+			if current == name {
+				return Profile{}, nil, fmt.Errorf("unknown profile '%s'", name)
+			}
+			return Profile{}, nil, fmt.Errorf("profile '%s' extends unknown profile '%s'", chain[len(chain)-1], current)
+		}
+		seen[current] = true
+		chain = append(chain, current)
+	}
+
+	// Apply from the base profile up so more specific profiles win
+	var merged Profile
+	for i := len(chain) - 1; i >= 0; i-- {
+		merged = overlayProfile(merged, profiles[chain[i]])
+	}
+	merged.Extends = profiles[name].Extends
+	return merged, chain, nil
+}
+
+// overlayProfile returns base with every value set in over applied on top.
+func overlayProfile(base, over Profile) Profile {
+	if over.TagsInclude != "" {
+		base.TagsInclude = over.TagsInclude
+	}
+	if over.TagsExclude != "" {
+		base.TagsExclude = over.TagsExclude
+	}
+	if over.Scope != "" {
+		base.Scope = over.Scope
+	}
+	if over.Prune != "" {
+		base.Prune = over.Prune
+	}
+	if over.Dedupe != "" {
+		base.Dedupe = over.Dedupe
+	}
+	if over.DeferEnv != nil {
+		base.DeferEnv = over.DeferEnv
+	}
+	return base
+}
+
+// validateProfiles checks every profile's values and extends chain.
+func validateProfiles(profiles map[string]Profile) error {
+	for name, p := range profiles {
+		context := fmt.Sprintf("profiles.%s", name)
+		if _, _, err := resolveProfile(profiles, name); err != nil {
+			return err
+		}
+		if err := validateProfileValues(p, context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateProfileValues checks the flag values set by a profile or by the
+// config defaults.
+func validateProfileValues(p Profile, context string) error {
+	if _, err := parseTagFlags(p.TagsInclude, p.TagsExclude); err != nil {
+		return fmt.Errorf("%v in %s", err, context)
+	}
+	if p.Scope != "" && !isValidScope(p.Scope) {
+		return fmt.Errorf("invalid scope '%s' in %s", p.Scope, context)
+	}
+	if p.Prune != "" && !isValidPrune(p.Prune) {
+		return fmt.Errorf("invalid prune '%s' in %s", p.Prune, context)
+	}
+	if p.Dedupe != "" && !isValidDedupe(p.Dedupe) {
+		return fmt.Errorf("invalid dedupe '%s' in %s", p.Dedupe, context)
+	}
+	return nil
+}
+
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the profiles defined in the config",
+	Run: func(cmd *cobra.Command, args []string) {
+		runProfiles()
+	},
+}
+
+func runProfiles() {
+	configPath := getConfigPath()
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(cfg.Profiles) == 0 {
+		fmt.Printf("No profiles defined in %s\n", configPath)
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		marker := " "
+		if name == activeProfile {
+			marker = "*"
+		}
+		header := name
+		if extends := cfg.Profiles[name].Extends; extends != "" {
+			header = fmt.Sprintf("%s (extends %s)", name, extends)
+		}
+		fmt.Printf("%s %s\n", marker, header)
+
+		resolved, _, err := resolveProfile(cfg.Profiles, name)
+		if err != nil {
+			fmt.Printf("    error: %v\n", err)
+			continue
+		}
+		for _, s := range settings {
+			if s.Config == nil {
+				continue
+			}
+			if value, ok := s.Config(resolved); ok {
+				fmt.Printf("    %s=%s\n", s.Flag, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeProfilesConfig(t *testing.T) string {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "profiles.yaml")
+	cfgContent := `all:
+  paths:
+    - "/tmp/pathuni/usr/local/bin"
+profiles:
+  base:
+    tags_exclude: gaming
+    prune: all
+  work:
+    extends: base
+    tags_include: work_*,dev
+    scope: pathuni
+    defer_env: false
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	return cfgPath
+}
+
+func TestProfiles_ResolveExtends(t *testing.T) {
+	yes := true
+	profiles := map[string]Profile{
+		"base": {TagsExclude: "gaming", Prune: "all", DeferEnv: &yes},
+		"work": {Extends: "base", TagsInclude: "work", Prune: "none"},
+	}
+	got, chain, err := resolveProfile(profiles, "work")
+	if err != nil {
+		t.Fatalf("resolveProfile: %v", err)
+	}
+	if !reflect.DeepEqual(chain, []string{"work", "base"}) {
+		t.Errorf("unexpected chain: %v", chain)
+	}
+	if got.TagsInclude != "work" || got.TagsExclude != "gaming" || got.Prune != "none" || got.DeferEnv == nil || !*got.DeferEnv {
+		t.Errorf("unexpected merged profile: %+v", got)
+	}
+
+	if _, _, err := resolveProfile(profiles, "missing"); err == nil || !strings.Contains(err.Error(), "unknown profile 'missing'") {
+		t.Errorf("expected unknown profile error, got %v", err)
+	}
+
+	cyclic := map[string]Profile{"a": {Extends: "b"}, "b": {Extends: "a"}}
+	if _, _, err := resolveProfile(cyclic, "a"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+	dangling := map[string]Profile{"a": {Extends: "nope"}}
+	if _, _, err := resolveProfile(dangling, "a"); err == nil || !strings.Contains(err.Error(), "extends unknown profile 'nope'") {
+		t.Errorf("expected dangling extends error, got %v", err)
+	}
+}
+
+func TestProfiles_Validate(t *testing.T) {
+	for name, profiles := range map[string]map[string]Profile{
+		"scope": {"p": {Scope: "everything"}},
+		"prune": {"p": {Prune: "some"}},
+		"tags":  {"p": {TagsInclude: "a,,b"}},
+	} {
+		if err := validateProfiles(profiles); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+	if err := validateProfiles(map[string]Profile{"p": {Scope: "system", Prune: "none"}}); err != nil {
+		t.Errorf("valid profile rejected: %v", err)
+	}
+}
+
+func TestProfiles_ApplyPrecedence(t *testing.T) {
+	cfgPath := writeProfilesConfig(t)
+	t.Setenv("PATHUNI_PROFILE", "")
+
+	cmd := newSettingsTestCmd(t, "-c", cfgPath, "--profile", "work", "-s", "full")
+	if err := resolveSettings(cmd); err != nil {
+		t.Fatalf("resolveSettings: %v", err)
+	}
+	if tagsInclude != "work_*,dev" || tagsExclude != "gaming" || prune != "all" || scope != "full" {
+		t.Errorf("unexpected settings: include=%q exclude=%q prune=%q scope=%q", tagsInclude, tagsExclude, prune, scope)
+	}
+	if activeProfile != "work" || activeProfileSource != "flag" {
+		t.Errorf("unexpected active profile %q from %q", activeProfile, activeProfileSource)
+	}
+	want := "config=flag, profile=flag, tags-include=profile, tags-exclude=profile, scope=flag, prune=profile, defer-env=profile"
+	if got := formatSettingSources(); got != want {
+		t.Errorf("setting sources:\n got: %s\nwant: %s", got, want)
+	}
+
+	// The environment selects a profile when the flag is absent
+	t.Setenv("PATHUNI_PROFILE", "base")
+	cmd = newSettingsTestCmd(t, "-c", cfgPath)
+	if err := resolveSettings(cmd); err != nil {
+		t.Fatalf("resolveSettings: %v", err)
+	}
+	if activeProfile != "base" || activeProfileSource != "env" || scope != "full" || prune != "all" {
+		t.Errorf("unexpected env profile state: %q/%q scope=%q prune=%q", activeProfile, activeProfileSource, scope, prune)
+	}
+	if settingSources["scope"] != "default" {
+		t.Errorf("expected scope from default, got %q", settingSources["scope"])
+	}
+
+	t.Setenv("PATHUNI_PROFILE", "nope")
+	if err := resolveSettings(newSettingsTestCmd(t, "-c", cfgPath)); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestProfiles_ListAndDryRunHeader(t *testing.T) {
+	setupTestFilesystem(t)
+
+	t.Setenv("PATHUNI_PROFILE", "")
+	cmd := newSettingsTestCmd(t, "-c", writeProfilesConfig(t), "-P", "work")
+	if err := resolveSettings(cmd); err != nil {
+		t.Fatalf("resolveSettings: %v", err)
+	}
+
+	list := captureOutput(runProfiles)
+	if !strings.Contains(list, "  base\n    tags-exclude=gaming\n    prune=all\n") {
+		t.Errorf("unexpected base listing:\n%s", list)
+	}
+	if !strings.Contains(list, "* work (extends base)\n    tags-include=work_*,dev\n") {
+		t.Errorf("expected active work profile marked, got:\n%s", list)
+	}
+
+	dry := captureDryRunOutput(func() { _ = PrintDryRunReport(config, "Linux", "bash", false, false, scope) })
+	if !strings.Contains(dry, "Profile: work (flag)\n") {
+		t.Errorf("expected profile header line, got:\n%s", dry)
+	}
+	if !strings.Contains(dry, "Source : config=flag, profile=flag, tags-include=profile, tags-exclude=profile, scope=profile, prune=profile, defer-env=profile\n") {
+		t.Errorf("expected setting sources header line, got:\n%s", dry)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+// Built-in providers for common toolchain managers. A platform section can list
+// `providers: [homebrew, cargo, ...]` instead of hand-writing the same entries;
+// each provider knows where its bin directories live by reading the tool's own
+// environment variables and config files (it never executes the tool).
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// providerFunc returns the bin directories of a toolchain manager for the given
+// platform ("macOS" or "Linux"), in PATH order.
+type providerFunc func(platform string) []string
+
+var providers = map[string]providerFunc{
+	"homebrew":    homebrewDirs,
+	"cargo":       cargoDirs,
+	"golang":      goDirs,
+	"npm_global":  npmGlobalDirs,
+	"pipx":        pipxDirs,
+	"asdf":        asdfDirs,
+	"mise":        miseDirs,
+	"nix_profile": nixProfileDirs,
+	"volta":       voltaDirs,
+	"pyenv":       pyenvDirs,
+	"rbenv":       rbenvDirs,
+	"sdkman":      sdkmanDirs,
+}
+
+// providerAliases maps alternative spellings to provider names. Provider names
+// double as tags, so they must satisfy tagRegex ("go" is too short).
+var providerAliases = map[string]string{
+	"go":   "golang",
+	"brew": "homebrew",
+}
+
+// canonicalProvider returns the registered name for a provider, resolving
+// aliases. The boolean is false for unknown providers.
+func canonicalProvider(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := providerAliases[name]; ok {
+		name = alias
+	}
+	_, ok := providers[name]
+	return name, ok
+}
+
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateProviders checks that every listed provider is known.
+func validateProviders(names []string, context string) error {
+	for _, name := range names {
+		if _, ok := canonicalProvider(name); !ok {
+			return fmt.Errorf("unknown provider '%s' in %s (available: %s)", name, context, strings.Join(providerNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// getProviderPathEntries expands the providers of a platform section into
+// PathEntry values tagged with the provider name.
+func getProviderPathEntries(names []string, platform string) []PathEntry {
+	var entries []PathEntry
+	for _, name := range names {
+		canonical, ok := canonicalProvider(name)
+		if !ok {
+			continue
+		}
+		for _, dir := range providers[canonical](platform) {
+			entries = append(entries, PathEntry{Path: dir, Tags: []string{canonical}, Provider: canonical})
+		}
+	}
+	return entries
+}
+
+// platformEntries returns the YAML entries of a platform section followed by
+// the entries generated by its providers.
+func platformEntries(pc PlatformConfig, context, platform string) ([]PathEntry, error) {
+	entries, err := extractPathEntries(pc.Paths, context)
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, getProviderPathEntries(pc.Providers, platform)...), nil
+}
+
+func homeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// envOr returns the value of env, or the fallback joined under $HOME.
+func envOr(env string, fallback ...string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return filepath.Join(append([]string{homeDir()}, fallback...)...)
+}
+
+// expandToolPath expands "~" and environment variables in values read from
+// tool config files. It fails on an unset variable instead of yielding a
+// path relative to "/".
+func expandToolPath(p string) (string, bool) {
+	expanded, err := expandPath(p, nil)
+	return expanded, err == nil
+}
+
+// readKeyValueFile parses simple `key=value` config files such as ~/.npmrc or
+// the go env file. Comments and blank lines are ignored; quotes are trimmed.
+func readKeyValueFile(file string) map[string]string {
+	values := make(map[string]string)
+	f, err := os.Open(file)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values
+}
+
+func homebrewDirs(platform string) []string {
+	prefix := os.Getenv("HOMEBREW_PREFIX")
+	if prefix == "" {
+		switch platform {
+		case "macOS":
+			prefix = homebrewMacPrefix()
+		case "Linux":
+			prefix = "/home/linuxbrew/.linuxbrew"
+			if local := filepath.Join(homeDir(), ".linuxbrew"); dirExists(local) {
+				prefix = local
+			}
+		}
+	}
+	if prefix == "" {
+		return nil
+	}
+	return []string{filepath.Join(prefix, "bin"), filepath.Join(prefix, "sbin")}
+}
+
+// homebrewMacPrefix returns the default Homebrew prefix on macOS:
+// /opt/homebrew on Apple silicon, /usr/local on Intel. The host architecture
+// only applies when pathuni runs on the Mac itself; under --os or --root the
+// prefix present on the target filesystem is used instead.
+func homebrewMacPrefix() string {
+	if runtime.GOOS == "darwin" && rootDir == "" && rootFS == nil {
+		if runtime.GOARCH == "arm64" {
+			return "/opt/homebrew"
+		}
+		return "/usr/local"
+	}
+	if dirExists("/opt/homebrew") {
+		return "/opt/homebrew"
+	}
+	return "/usr/local"
+}
+
+func cargoDirs(string) []string {
+	return []string{filepath.Join(envOr("CARGO_HOME", ".cargo"), "bin")}
+}
+
+// goDirs mirrors `go env GOBIN`/`go env GOPATH` without running go: explicit
+// environment first, then the go env file written by `go env -w`.
+func goDirs(string) []string {
+	goEnvFile := os.Getenv("GOENV")
+	if goEnvFile == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			goEnvFile = filepath.Join(dir, "go", "env")
+		}
+	}
+	fileValues := readKeyValueFile(goEnvFile)
+
+	gobin := os.Getenv("GOBIN")
+	if gobin == "" {
+		gobin = fileValues["GOBIN"]
+	}
+	if gobin != "" {
+		if dir, ok := expandToolPath(gobin); ok {
+			return []string{dir}
+		}
+		return nil
+	}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = fileValues["GOPATH"]
+	}
+	if gopath == "" {
+		return []string{filepath.Join(homeDir(), "go", "bin")}
+	}
+	var dirs []string
+	for _, p := range filepath.SplitList(gopath) {
+		if dir, ok := expandToolPath(p); ok && p != "" {
+			dirs = append(dirs, filepath.Join(dir, "bin"))
+		}
+	}
+	return dirs
+}
+
+// npmGlobalDirs only reports a directory when a user prefix is configured;
+// npm's built-in prefix is a system location already on PATH.
+func npmGlobalDirs(string) []string {
+	prefix := os.Getenv("NPM_CONFIG_PREFIX")
+	if prefix == "" {
+		prefix = os.Getenv("npm_config_prefix")
+	}
+	if prefix == "" {
+		npmrc := os.Getenv("NPM_CONFIG_USERCONFIG")
+		if npmrc == "" {
+			npmrc = filepath.Join(homeDir(), ".npmrc")
+		}
+		prefix = readKeyValueFile(npmrc)["prefix"]
+	}
+	if prefix == "" {
+		return nil
+	}
+	dir, ok := expandToolPath(prefix)
+	if !ok {
+		return nil
+	}
+	return []string{filepath.Join(dir, "bin")}
+}
+
+func pipxDirs(string) []string {
+	return []string{envOr("PIPX_BIN_DIR", ".local", "bin")}
+}
+
+func asdfDirs(string) []string {
+	return []string{filepath.Join(envOr("ASDF_DATA_DIR", ".asdf"), "shims")}
+}
+
+func miseDirs(string) []string {
+	dataDir := os.Getenv("MISE_DATA_DIR")
+	if dataDir == "" {
+		dataDir = filepath.Join(xdgBaseDir("XDG_DATA_HOME", ".local", "share"), "mise")
+	}
+	return []string{filepath.Join(dataDir, "shims")}
+}
+
+func nixProfileDirs(string) []string {
+	return []string{
+		filepath.Join(homeDir(), ".nix-profile", "bin"),
+		filepath.Join(xdgBaseDir("XDG_STATE_HOME", ".local", "state"), "nix", "profile", "bin"),
+		"/nix/var/nix/profiles/default/bin",
+	}
+}
+
+func voltaDirs(string) []string {
+	return []string{filepath.Join(envOr("VOLTA_HOME", ".volta"), "bin")}
+}
+
+func pyenvDirs(string) []string {
+	root := envOr("PYENV_ROOT", ".pyenv")
+	return []string{filepath.Join(root, "shims"), filepath.Join(root, "bin")}
+}
+
+func rbenvDirs(string) []string {
+	root := envOr("RBENV_ROOT", ".rbenv")
+	return []string{filepath.Join(root, "shims"), filepath.Join(root, "bin")}
+}
+
+// sdkmanDirs lists the current version of every installed candidate.
+func sdkmanDirs(string) []string {
+	matches := globPath(filepath.Join(envOr("SDKMAN_DIR", ".sdkman"), "candidates", "*", "current", "bin"))
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProviders_Dirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Run("cargo honours CARGO_HOME", func(t *testing.T) {
+		t.Setenv("CARGO_HOME", "/opt/cargo")
+		if got := cargoDirs("Linux"); !reflect.DeepEqual(got, []string{"/opt/cargo/bin"}) {
+			t.Errorf("cargoDirs() = %v", got)
+		}
+	})
+
+	t.Run("go reads the go env file", func(t *testing.T) {
+		envFile := filepath.Join(home, "goenv")
+		if err := os.WriteFile(envFile, []byte("GOPATH=/srv/go:/opt/go\n"), 0644); err != nil {
+			t.Fatalf("write go env: %v", err)
+		}
+		t.Setenv("GOENV", envFile)
+		t.Setenv("GOBIN", "")
+		t.Setenv("GOPATH", "")
+		want := []string{"/srv/go/bin", "/opt/go/bin"}
+		if got := goDirs("Linux"); !reflect.DeepEqual(got, want) {
+			t.Errorf("goDirs() = %v, want %v", got, want)
+		}
+
+		t.Setenv("GOBIN", "/usr/local/gobin")
+		if got := goDirs("Linux"); !reflect.DeepEqual(got, []string{"/usr/local/gobin"}) {
+			t.Errorf("GOBIN should win, got %v", got)
+		}
+	})
+
+	t.Run("npm_global reads npmrc prefix", func(t *testing.T) {
+		t.Setenv("NPM_CONFIG_PREFIX", "")
+		t.Setenv("npm_config_prefix", "")
+		t.Setenv("NPM_CONFIG_USERCONFIG", "")
+		if got := npmGlobalDirs("Linux"); got != nil {
+			t.Errorf("expected no dirs without a user prefix, got %v", got)
+		}
+		if err := os.WriteFile(filepath.Join(home, ".npmrc"), []byte("# comment\nprefix=~/.npm-global\n"), 0644); err != nil {
+			t.Fatalf("write npmrc: %v", err)
+		}
+		want := []string{filepath.Join(home, ".npm-global", "bin")}
+		if got := npmGlobalDirs("Linux"); !reflect.DeepEqual(got, want) {
+			t.Errorf("npmGlobalDirs() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("homebrew prefix follows the target filesystem", func(t *testing.T) {
+		t.Setenv("HOMEBREW_PREFIX", "")
+		withRootFS(t, fstest.MapFS{"opt/homebrew/bin": {Mode: fs.ModeDir | 0755}})
+		if got := homebrewDirs("macOS"); got[0] != "/opt/homebrew/bin" {
+			t.Errorf("expected the Apple silicon prefix, got %v", got)
+		}
+		withRootFS(t, fstest.MapFS{"usr/local/bin": {Mode: fs.ModeDir | 0755}})
+		if got := homebrewDirs("macOS"); got[0] != "/usr/local/bin" {
+			t.Errorf("expected the Intel prefix, got %v", got)
+		}
+	})
+
+	t.Run("sdkman lists current candidates", func(t *testing.T) {
+		t.Setenv("SDKMAN_DIR", "")
+		for _, c := range []string{"java", "gradle"} {
+			if err := os.MkdirAll(filepath.Join(home, ".sdkman", "candidates", c, "current", "bin"), 0755); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+		}
+		want := []string{
+			filepath.Join(home, ".sdkman", "candidates", "gradle", "current", "bin"),
+			filepath.Join(home, ".sdkman", "candidates", "java", "current", "bin"),
+		}
+		if got := sdkmanDirs("Linux"); !reflect.DeepEqual(got, want) {
+			t.Errorf("sdkmanDirs() = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestProviders_AliasesAndValidation(t *testing.T) {
+	if name, ok := canonicalProvider("go"); !ok || name != "golang" {
+		t.Errorf("expected go alias to resolve to golang, got %q (%v)", name, ok)
+	}
+	for _, name := range providerNames() {
+		if !tagRegex.MatchString(name) {
+			t.Errorf("provider name %q is not a valid tag", name)
+		}
+	}
+	if err := validateProviders([]string{"cargo", "nopenope"}, "all.providers"); err == nil {
+		t.Error("expected unknown provider to be rejected")
+	}
+}
+
+func TestProviders_Evaluation(t *testing.T) {
+	setupTestFilesystem(t)
+
+	t.Setenv("CARGO_HOME", "/tmp/pathuni/home/Pratt/.cargo")
+	t.Setenv("PIPX_BIN_DIR", "/tmp/pathuni/home/Pratt/.local/bin")
+
+	cfgPath := filepath.Join(t.TempDir(), "providers.yaml")
+	cfgContent := "all:\n  paths:\n    - \"/tmp/pathuni/usr/local/bin\"\n  providers: [cargo, pipx]\n"
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+
+	valid, _, _, err := EvaluateConfig(cfgPath, "Linux", "bash", TagFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"/tmp/pathuni/usr/local/bin", "/tmp/pathuni/home/Pratt/.cargo/bin", "/tmp/pathuni/home/Pratt/.local/bin"}
+	if !reflect.DeepEqual(valid, want) {
+		t.Errorf("expected provider entries after YAML entries, got %v", valid)
+	}
+
+	// Provider entries are tagged with the provider name
+	filter, _ := parseTagFlags("", "cargo")
+	valid, skipped, _, err := EvaluateConfig(cfgPath, "Linux", "bash", filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0] != "/tmp/pathuni/home/Pratt/.cargo/bin" || len(valid) != 2 {
+		t.Errorf("expected cargo provider to be excluded by tag, valid=%v skipped=%v", valid, skipped)
+	}
+
+	prune = "pathuni"
+	tagsInclude, tagsExclude = "", ""
+	out := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "pathuni") })
+	if !strings.Contains(out, "Providers:\n  cargo\n    [+] /tmp/pathuni/home/Pratt/.cargo/bin\n  pipx\n") {
+		t.Errorf("expected dry-run output grouped by provider, got:\n%s", out)
+	}
+	if strings.Count(out, "/tmp/pathuni/home/Pratt/.cargo/bin") != 1 || !strings.Contains(out, "1 Included Path:\n") {
+		t.Errorf("provider entries should only be listed in their group, got:\n%s", out)
+	}
+
+	// Unknown providers are a config error
+	if err := os.WriteFile(cfgPath, []byte("linux:\n  providers: [cargoo]\n"), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	if _, _, _, err := EvaluateConfig(cfgPath, "Linux", "bash", TagFilter{}); err == nil {
+		t.Error("expected unknown provider to fail config validation")
+	}
+}
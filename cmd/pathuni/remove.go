@@ -0,0 +1,325 @@
+package main
+
+// Removal of unwanted system PATH entries. A platform section can list
+// `remove:` patterns (exact paths or globs); matching entries are dropped from
+// the system side before merging.
+//
+// Globs use filepath.Match syntax, restricted so that no wildcard or class
+// matches "/". That keeps "*" within one path component in every shell the
+// patterns are translated for: a regexp for Go, fish and PowerShell, and a
+// case pattern plus a component-count guard for POSIX shells.
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// removedPath is a system entry dropped by a remove pattern.
+type removedPath struct {
+	Path    string
+	Pattern string
+}
+
+// validateRemovePatterns checks that every glob pattern is well formed.
+func validateRemovePatterns(patterns []string, context string) error {
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("empty pattern in %s", context)
+		}
+		if _, err := parseGlob(pattern); err != nil {
+			return fmt.Errorf("invalid pattern '%s' in %s: %v", pattern, context, err)
+		}
+	}
+	return nil
+}
+
+// removePatterns returns the remove patterns that apply to platform: the
+// "all" section first, then the platform section.
+func removePatterns(cfg *Config, platform string) []string {
+	patterns := append([]string{}, cfg.All.Remove...)
+	switch platform {
+	case "macOS":
+		patterns = append(patterns, cfg.MacOS.Remove...)
+	case "Linux":
+		patterns = append(patterns, cfg.Linux.Remove...)
+	}
+	return patterns
+}
+
+// configRemovePatterns reads the remove patterns for platform from the config
+// file, expanded for use in generated shell code.
+func configRemovePatterns(configPath, platform string) []string {
+	cfg, err := readConfigFiles(configPath)
+	if err != nil {
+		return nil
+	}
+	var patterns []string
+	for _, p := range removePatterns(cfg, platform) {
+		if expanded, ok := expandRemovePattern(p, cfg.Vars); ok {
+			patterns = append(patterns, expanded)
+		}
+	}
+	return patterns
+}
+
+// expandRemovePattern expands a pattern against the config vars and the
+// environment. A pattern referencing an unset variable is skipped, so
+// "$OLD_SDK/bin" never turns into "/bin".
+func expandRemovePattern(pattern string, vars map[string]string) (string, bool) {
+	expanded, ok := expandOrSkip("remove pattern", pattern, vars)
+	if !ok {
+		return "", false
+	}
+	return filepath.Clean(expanded), true
+}
+
+// matchRemovePattern reports whether entry matches an expanded pattern. Exact
+// patterns compare cleaned paths and glob patterns match through globRegexp,
+// the same expression the fish and PowerShell code uses.
+func matchRemovePattern(pattern, entry string) bool {
+	entry = filepath.Clean(entry)
+	if !isGlobPath(pattern) {
+		return pattern == entry
+	}
+	tokens, err := parseGlob(pattern)
+	if err != nil {
+		return false
+	}
+	return regexp.MustCompile("^" + globRegexp(tokens) + "$").MatchString(entry)
+}
+
+// isGlobPath reports whether a remove pattern uses glob syntax. A backslash
+// counts, since it escapes the character after it.
+func isGlobPath(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// globToken is one element of a parsed glob: a literal rune, "*", "?" or a
+// character class.
+type globToken struct {
+	kind    byte // 'l', '*', '?' or '['
+	r       rune
+	negated bool
+	ranges  []globRange
+}
+
+// globRange is a class item; single characters have lo == hi.
+type globRange struct {
+	lo, hi rune
+}
+
+// parseGlob splits a filepath.Match pattern into tokens. It rejects malformed
+// patterns like filepath.Match does, and classes that could match "/".
+func parseGlob(pattern string) ([]globToken, error) {
+	var tokens []globToken
+	for i := 0; i < len(pattern); {
+		switch pattern[i] {
+		case '*':
+			tokens = append(tokens, globToken{kind: '*'})
+			i++
+		case '?':
+			tokens = append(tokens, globToken{kind: '?'})
+			i++
+		case '[':
+			tok, n, err := parseGlobClass(pattern[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += 1 + n
+		default:
+			r, n, err := globRune(pattern[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, globToken{kind: 'l', r: r})
+			i += n
+		}
+	}
+	return tokens, nil
+}
+
+// parseGlobClass parses a class body following "[" and returns the number of
+// bytes consumed, including the closing "]".
+func parseGlobClass(s string) (globToken, int, error) {
+	tok := globToken{kind: '['}
+	i := 0
+	if i < len(s) && s[i] == '^' {
+		tok.negated = true
+		i++
+	}
+	for {
+		if i >= len(s) {
+			return tok, 0, filepath.ErrBadPattern
+		}
+		if s[i] == ']' && len(tok.ranges) > 0 {
+			i++
+			break
+		}
+		lo, n, err := globClassRune(s[i:])
+		if err != nil {
+			return tok, 0, err
+		}
+		i += n
+		hi := lo
+		if i < len(s) && s[i] == '-' {
+			if hi, n, err = globClassRune(s[i+1:]); err != nil {
+				return tok, 0, err
+			}
+			if hi < lo {
+				return tok, 0, filepath.ErrBadPattern
+			}
+			i += 1 + n
+		}
+		if !tok.negated && lo <= '/' && '/' <= hi {
+			return tok, 0, fmt.Errorf("character class cannot match '/'")
+		}
+		tok.ranges = append(tok.ranges, globRange{lo: lo, hi: hi})
+	}
+	return tok, i, nil
+}
+
+// globRune decodes one possibly escaped rune outside a class.
+func globRune(s string) (rune, int, error) {
+	if s[0] == '\\' {
+		if len(s) == 1 {
+			return 0, 0, filepath.ErrBadPattern
+		}
+		r, n := utf8.DecodeRuneInString(s[1:])
+		return r, 1 + n, nil
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	return r, n, nil
+}
+
+// globClassRune decodes one possibly escaped rune inside a class, where an
+// unescaped "-" or "]" is malformed as in filepath.Match.
+func globClassRune(s string) (rune, int, error) {
+	if len(s) == 0 || s[0] == '-' || s[0] == ']' {
+		return 0, 0, filepath.ErrBadPattern
+	}
+	return globRune(s)
+}
+
+// globRegexp translates tokens to an unanchored regexp that Go, PCRE (fish)
+// and .NET (PowerShell) read alike.
+func globRegexp(tokens []globToken) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		switch tok.kind {
+		case '*':
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			b.WriteString(renderGlobClass(tok, "^", regexpClassRune))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(tok.r)))
+		}
+	}
+	return b.String()
+}
+
+// globCasePattern translates tokens to a POSIX case pattern. Only "*" can
+// match "/" there, which the caller guards against with globSlashes.
+func globCasePattern(tokens []globToken) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		switch tok.kind {
+		case '*':
+			b.WriteString("*")
+		case '?':
+			b.WriteString("[!/]")
+		case '[':
+			b.WriteString(renderGlobClass(tok, "!", caseRune))
+		default:
+			b.WriteString(caseRune(tok.r))
+		}
+	}
+	return b.String()
+}
+
+// globSlashes counts the "/" a matching path contains.
+func globSlashes(tokens []globToken) int {
+	n := 0
+	for _, tok := range tokens {
+		if tok.kind == 'l' && tok.r == '/' {
+			n++
+		}
+	}
+	return n
+}
+
+// renderGlobClass writes a class with the given negation marker; negated
+// classes also exclude "/".
+func renderGlobClass(tok globToken, negate string, quote func(rune) string) string {
+	var b strings.Builder
+	b.WriteString("[")
+	if tok.negated {
+		b.WriteString(negate)
+	}
+	for _, rg := range tok.ranges {
+		b.WriteString(quote(rg.lo))
+		if rg.hi != rg.lo {
+			b.WriteString("-" + quote(rg.hi))
+		}
+	}
+	if tok.negated {
+		b.WriteString("/")
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// regexpClassRune escapes ASCII punctuation inside a regexp class.
+func regexpClassRune(r rune) string {
+	if r < utf8.RuneSelf && !isAlnum(byte(r)) && r > ' ' {
+		return `\` + string(r)
+	}
+	return string(r)
+}
+
+// caseRune escapes a literal for an unquoted POSIX case pattern.
+func caseRune(r rune) string {
+	if r >= utf8.RuneSelf || isAlnum(byte(r)) || strings.ContainsRune("/._-+,@%=:", r) {
+		return string(r)
+	}
+	return `\` + string(r)
+}
+
+// applyRemovePatterns splits entries into those kept and those removed, the
+// latter recorded with the first pattern that matched, as written.
+func applyRemovePatterns(entries, patterns []string, vars map[string]string) (kept []string, removed []removedPath) {
+	if len(patterns) == 0 {
+		return entries, nil
+	}
+	expanded := make(map[string]string, len(patterns))
+	for _, pattern := range patterns {
+		if e, ok := expandRemovePattern(pattern, vars); ok {
+			expanded[pattern] = e
+		}
+	}
+	for _, entry := range entries {
+		matchedBy := ""
+		for _, pattern := range patterns {
+			if e, ok := expanded[pattern]; ok && matchRemovePattern(e, entry) {
+				matchedBy = pattern
+				break
+			}
+		}
+		if matchedBy != "" {
+			removed = append(removed, removedPath{Path: entry, Pattern: matchedBy})
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, removed
+}
+
+// renderRemovedPath renders a removed system entry for dry-run output.
+func renderRemovedPath(r removedPath) string {
+	return fmt.Sprintf("  [x] %s\n       └removed by %s", r.Path, r.Pattern)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRemove_ApplyPatterns(t *testing.T) {
+	t.Setenv("OLD_SDK", "/opt/old-sdk")
+	entries := []string{"/usr/local/bin", "/usr/games", "/snap/bin", "/opt/old-sdk/bin", "/usr/bin/"}
+	patterns := []string{"/usr/games", "/snap/*", "$OLD_SDK/bin", "/usr/bin"}
+
+	kept, removed := applyRemovePatterns(entries, patterns, nil)
+	if !reflect.DeepEqual(kept, []string{"/usr/local/bin"}) {
+		t.Errorf("unexpected kept entries: %v", kept)
+	}
+	want := []removedPath{
+		{Path: "/usr/games", Pattern: "/usr/games"},
+		{Path: "/snap/bin", Pattern: "/snap/*"},
+		{Path: "/opt/old-sdk/bin", Pattern: "$OLD_SDK/bin"},
+		{Path: "/usr/bin/", Pattern: "/usr/bin"},
+	}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("unexpected removed entries:\n got: %v\nwant: %v", removed, want)
+	}
+
+	if err := validateRemovePatterns([]string{"/opt/[bad"}, "linux.remove"); err == nil {
+		t.Error("expected malformed glob to be rejected")
+	}
+	if err := validateRemovePatterns([]string{"/opt/a[/]b"}, "linux.remove"); err == nil {
+		t.Error("expected a class matching '/' to be rejected")
+	}
+}
+
+func writeRemoveConfig(t *testing.T) string {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "remove.yaml")
+	cfgContent := `all:
+  paths:
+    - "/tmp/pathuni/usr/local/bin"
+  remove:
+    - "/tmp/pathuni/usr/games"
+linux:
+  remove:
+    - "/tmp/pathuni/snap/*"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	return cfgPath
+}
+
+func TestRemove_InitAndDryRun(t *testing.T) {
+	setupTestFilesystem(t)
+
+	config = writeRemoveConfig(t)
+	osOverride = "Linux"
+	shell = "bash"
+	prune = "pathuni"
+	deferEnv = false
+	tagsInclude, tagsExclude = "", ""
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/usr/games:/tmp/pathuni/snap/bin:/tmp/pathuni/bin")
+
+	scope = "full"
+	out := captureOutput(runInit)
+	want := `export PATH="/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/bin"` + "\n"
+	if out != want {
+		t.Errorf("init full mismatch:\nwant: %q\n got: %q", want, out)
+	}
+
+	dry := captureDryRunOutput(func() { _ = PrintDryRunReport(config, "Linux", "bash", false, false, "system") })
+	if !strings.Contains(dry, "[x] /tmp/pathuni/usr/games\n       └removed by /tmp/pathuni/usr/games") {
+		t.Errorf("expected removed marker in dry-run, got:\n%s", dry)
+	}
+	if !strings.Contains(dry, "[x] /tmp/pathuni/snap/bin\n       └removed by /tmp/pathuni/snap/*") {
+		t.Errorf("expected glob removal in dry-run, got:\n%s", dry)
+	}
+	if !strings.Contains(dry, "2 System paths skipped in total") {
+		t.Errorf("expected removed entries counted as skipped, got:\n%s", dry)
+	}
+
+	// macOS does not pick up the linux section
+	dry = captureDryRunOutput(func() { _ = PrintDryRunReport(config, "macOS", "bash", false, false, "system") })
+	if strings.Contains(dry, "[x] /tmp/pathuni/snap/bin") {
+		t.Errorf("linux remove patterns should not apply on macOS, got:\n%s", dry)
+	}
+}
+
+func TestRemove_DeferStripsLivePath(t *testing.T) {
+	setupTestFilesystem(t)
+
+	config = writeRemoveConfig(t)
+	osOverride = "Linux"
+	prune = "pathuni"
+	scope = "full"
+	tagsInclude, tagsExclude = "", ""
+	livePath := "/tmp/pathuni/usr/bin:/tmp/pathuni/usr/games:/tmp/pathuni/snap/bin:/tmp/pathuni/bin"
+	t.Setenv("PATH", livePath)
+
+	for _, sh := range []string{"sh", "bash", "dash"} {
+		bin, err := exec.LookPath(sh)
+		if err != nil {
+			continue
+		}
+		shell = sh
+		deferEnv = true
+		code := captureOutput(runInit)
+		deferEnv = false
+
+		cmd := exec.Command(bin, "-c", code+`printf %s "$PATH"`)
+		cmd.Env = []string{"PATH=" + livePath}
+		got, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: running generated code failed: %v\n%s", sh, err, code)
+		}
+		want := "/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/bin"
+		if string(got) != want {
+			t.Errorf("%s: PATH after defer code = %q, want %q\ncode:\n%s", sh, got, want, code)
+		}
+	}
+
+	fish := renderFishDefer(deferPlan{Prefix: []string{"/a"}, Remove: []string{"/snap/*"}})
+	if fish != "set -gx PATH (string match -rv -- '^(?:/snap/[^/]*)$' $PATH); set -gx PATH (for __pathuni_e in $PATH; contains -- $__pathuni_e '/a'; or printf '%s\\n' $__pathuni_e; end); set -e __pathuni_e; set -gx PATH /a $PATH" {
+		t.Errorf("unexpected fish removal code: %s", fish)
+	}
+	pwsh := renderPwshDefer(deferPlan{Remove: []string{"/usr/games", "/snap/*"}})
+	if !strings.Contains(pwsh, "Where-Object { $_ -cnotmatch '^(?:/usr/games|/snap/[^/]*)$' }") {
+		t.Errorf("unexpected PowerShell removal code: %s", pwsh)
+	}
+}
+
+// TestRemove_DeferMatchesEvaluation checks that the generated removal code
+// drops exactly the entries applyRemovePatterns drops, in every POSIX shell
+// available and through the regexp used for fish and PowerShell.
+func TestRemove_DeferMatchesEvaluation(t *testing.T) {
+	patterns := []string{"/snap/*", "/opt/*/bin", "/usr/lib?", "/x/[^a]b", "/y/[a-c]d", `/lit/\*`, "/usr/games", "/a/*b*"}
+	entries := []string{
+		"/snap/bin", "/snap/bin/x", "/opt/a/bin", "/opt/a/b/bin", "/usr/lib", "/usr/lib6",
+		"/x/zb", "/x/ab", "/y/bd", "/y/dd", "/lit/*", "/lit/a", "/usr/games", "/usr/games/x",
+		"/a/xbx", "/a/b/b", "/a/b",
+	}
+	kept, _ := applyRemovePatterns(entries, patterns, nil)
+	want := strings.Join(kept, ":")
+	if want != "/snap/bin/x:/opt/a/b/bin:/usr/lib:/x/ab:/y/dd:/lit/a:/usr/games/x:/a/b/b" {
+		t.Fatalf("unexpected kept entries: %s", want)
+	}
+
+	re := regexp.MustCompile(removeRegexp(patterns))
+	var regexpKept []string
+	for _, e := range entries {
+		if !re.MatchString(e) {
+			regexpKept = append(regexpKept, e)
+		}
+	}
+	if got := strings.Join(regexpKept, ":"); got != want {
+		t.Errorf("regexp kept %q, want %q", got, want)
+	}
+
+	code := renderBashRemove(patterns)
+	for _, sh := range []string{"sh", "bash", "dash", "zsh"} {
+		bin, err := exec.LookPath(sh)
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(bin, "-c", code+`; printf %s "$PATH"`)
+		cmd.Env = []string{"PATH=" + strings.Join(entries, ":")}
+		got, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: running generated code failed: %v\n%s", sh, err, code)
+		}
+		if string(got) != want {
+			t.Errorf("%s: kept %q, want %q\ncode:\n%s", sh, got, want, code)
+		}
+
+		// Empty entries stand for the current directory and are kept
+		cmd = exec.Command(bin, "-c", renderBashStrip([]string{"/a"})+`; printf %s "$PATH"`)
+		cmd.Env = []string{"PATH=::/a:/b:"}
+		if got, err := cmd.Output(); err != nil || string(got) != "::/b:" {
+			t.Errorf("%s: stripping /a from %q gave %q (%v)", sh, "::/a:/b:", got, err)
+		}
+	}
+}
+
+func TestRemove_UnsetVariableSkipsPattern(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "unset.yaml")
+	writeFile(t, cfgPath, `vars:
+  GAMES: /tmp/pathuni/usr/games
+all:
+  paths:
+    - "/tmp/pathuni/usr/local/bin"
+  remove:
+    - "$PATHUNI_TEST_UNSET_SDK/bin"
+    - "$GAMES"
+    - "${PATHUNI_TEST_UNSET_SNAP:-/tmp/pathuni/snap}/*"
+`)
+	config = cfgPath
+	osOverride = "Linux"
+	shell = "bash"
+	scope = "full"
+	prune = "pathuni"
+	tagsInclude, tagsExclude = "", ""
+	livePath := "/tmp/pathuni/usr/bin:/tmp/pathuni/usr/games:/tmp/pathuni/snap/bin:/tmp/pathuni/bin"
+	t.Setenv("PATH", livePath)
+
+	if got := configRemovePatterns(cfgPath, "Linux"); !reflect.DeepEqual(got, []string{"/tmp/pathuni/usr/games", "/tmp/pathuni/snap/*"}) {
+		t.Errorf("unexpected expanded patterns: %v", got)
+	}
+
+	deferEnv = false
+	want := `export PATH="/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/bin"` + "\n"
+	if out := captureOutput(runInit); out != want {
+		t.Errorf("init mismatch:\nwant: %q\n got: %q", want, out)
+	}
+
+	if sh, err := exec.LookPath("sh"); err == nil {
+		deferEnv = true
+		code := captureOutput(runInit)
+		deferEnv = false
+		cmd := exec.Command(sh, "-c", code+`printf %s "$PATH"`)
+		cmd.Env = []string{"PATH=" + livePath}
+		got, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("running generated code failed: %v\n%s", err, code)
+		}
+		if string(got) != "/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/bin" {
+			t.Errorf("defer code left PATH at %q\n%s", got, code)
+		}
+	}
+}
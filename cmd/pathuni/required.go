@@ -0,0 +1,116 @@
+package main
+
+// Required entries. Pruning silently drops missing directories, which hides a
+// misprovisioned machine when the directory is essential. Entries marked
+// `required: true` are still pruned, but init emits a shell-level warning for
+// each missing one and --strict turns them into a dedicated exit code.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// exitRequiredMissing is the exit code used by --strict when a required
+// entry is missing.
+const exitRequiredMissing = 3
+
+// strict makes commands fail when required entries are missing.
+var strict bool
+
+// extractRequired reads the optional 'required' field of a path object.
+func extractRequired(v map[string]interface{}, context string, i int) (bool, error) {
+	raw, ok := v["required"]
+	if !ok {
+		return false, nil
+	}
+	required, isBool := raw.(bool)
+	if !isBool {
+		return false, fmt.Errorf("invalid required in %s at index %d: expected boolean", context, i)
+	}
+	return required, nil
+}
+
+// missingRequired returns the required entries that do not exist. Entries
+// excluded by tag filtering are not required in this context.
+func missingRequired(statuses []PathStatus) []string {
+	var missing []string
+	for _, st := range statuses {
+		if st.Required && !st.Exists {
+			missing = append(missing, st.Path)
+		}
+	}
+	return missing
+}
+
+func requiredWarning(path string) string {
+	return fmt.Sprintf("pathuni: required path %s does not exist", path)
+}
+
+// renderersWarn render a warning printed by the shell when the init code is
+// evaluated, so it shows up in the user's terminal.
+var renderersWarn = map[string]func(string) string{
+	"bash":       renderBashWarn,
+	"zsh":        renderBashWarn,
+	"sh":         renderBashWarn,
+	"dash":       renderBashWarn,
+	"ash":        renderBashWarn,
+	"ksh":        renderBashWarn,
+	"mksh":       renderBashWarn,
+	"yash":       renderBashWarn,
+	"fish":       renderFishWarn,
+	"powershell": renderPwshWarn,
+}
+
+func renderBashWarn(msg string) string {
+	return "echo " + shQuote(msg) + " >&2"
+}
+
+func renderFishWarn(msg string) string {
+	return "echo " + fishQuote(msg) + " >&2"
+}
+
+func renderPwshWarn(msg string) string {
+	return "Write-Warning " + pwshQuote(msg)
+}
+
+// exitOnMissingRequired exits with exitRequiredMissing under --strict when
+// required entries are missing.
+func exitOnMissingRequired(missing []string) {
+	if !strict || len(missing) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error: missing required path(s): %s\n", strings.Join(missing, ", "))
+	os.Exit(exitRequiredMissing)
+}
+
+// warnMissingRequired prints a shell warning for each missing required entry
+// after the init code, then applies --strict.
+func warnMissingRequired(shellName string, missing []string) {
+	for _, p := range missing {
+		fmt.Println(renderersWarn[shellName](requiredWarning(p)))
+	}
+	exitOnMissingRequired(missing)
+}
+
+// reportMissingRequired prints a warning on stderr for each missing required
+// entry, for commands whose output is not shell code, then applies --strict.
+func reportMissingRequired(missing []string) {
+	for _, p := range missing {
+		fmt.Fprintln(os.Stderr, requiredWarning(p))
+	}
+	exitOnMissingRequired(missing)
+}
+
+// printMissingRequired prints the dry-run summary line for missing required
+// entries.
+func printMissingRequired(missing []string) {
+	switch len(missing) {
+	case 0:
+		return
+	case 1:
+		fmt.Printf("1 Required path missing: %s\n", missing[0])
+	default:
+		fmt.Printf("%d Required paths missing: %s\n", len(missing), strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const requiredTestConfig = `all:
+  paths:
+    - /tmp/pathuni/usr/local/bin
+    - path: /tmp/pathuni/usr/local/bin
+      required: true
+    - path: /tmp/pathuni/corp/bin
+      required: true
+    - path: /tmp/pathuni/work/bin
+      required: true
+      tags: [work]
+    - /tmp/pathuni/optional/bin
+`
+
+func TestRequired_MissingEntries(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "required.yaml")
+	writeFile(t, cfgPath, requiredTestConfig)
+
+	tests := []struct {
+		filter TagFilter
+		want   []string
+	}{
+		{TagFilter{}, []string{"/tmp/pathuni/corp/bin", "/tmp/pathuni/work/bin"}},
+		{TagFilter{Exclude: [][]string{{"work"}}}, []string{"/tmp/pathuni/corp/bin"}},
+	}
+	for _, tt := range tests {
+		statuses, _, err := EvaluateConfigDetailed(cfgPath, "Linux", "bash", tt.filter)
+		if err != nil {
+			t.Fatalf("EvaluateConfigDetailed: %v", err)
+		}
+		if got := missingRequired(statuses); strings.Join(got, ":") != strings.Join(tt.want, ":") {
+			t.Errorf("filter %+v: got %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestRequired_InvalidValue(t *testing.T) {
+	entry := map[string]interface{}{"path": "/opt/corp/bin", "required": "yes"}
+	_, err := extractPathEntries([]interface{}{entry}, "all.paths")
+	if err == nil || err.Error() != "invalid required in all.paths at index 0: expected boolean" {
+		t.Errorf("expected invalid required error, got %v", err)
+	}
+}
+
+func TestRequired_InitWarnings(t *testing.T) {
+	setupTestFilesystem(t)
+
+	config = filepath.Join(t.TempDir(), "required.yaml")
+	writeFile(t, config, requiredTestConfig)
+	osOverride = "Linux"
+	prune = "pathuni"
+	scope = "pathuni"
+	deferEnv = false
+	t.Cleanup(func() { config, osOverride, shell, scope = "", "", "", "full" })
+
+	tests := []struct {
+		shell, want string
+	}{
+		{"bash", "export PATH=\"/tmp/pathuni/usr/local/bin\"\necho 'pathuni: required path /tmp/pathuni/corp/bin does not exist' >&2\n"},
+		{"fish", "echo 'pathuni: required path /tmp/pathuni/work/bin does not exist' >&2\n"},
+		{"powershell", "Write-Warning 'pathuni: required path /tmp/pathuni/corp/bin does not exist'\n"},
+	}
+	for _, tt := range tests {
+		shell = tt.shell
+		out := captureOutput(func() { runInit() })
+		if !strings.Contains(out, tt.want) {
+			t.Errorf("%s init missing %q:\n%s", tt.shell, tt.want, out)
+		}
+	}
+
+	// System scope does not use pathuni entries
+	scope = "system"
+	shell = "bash"
+	if out := captureOutput(func() { runInit() }); strings.Contains(out, "required path") {
+		t.Errorf("system scope should not warn about required entries:\n%s", out)
+	}
+}
+
+func TestRequired_DryRunMarker(t *testing.T) {
+	setupTestFilesystem(t)
+	prune = "pathuni"
+
+	cfgPath := filepath.Join(t.TempDir(), "required.yaml")
+	writeFile(t, cfgPath, requiredTestConfig)
+
+	out := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "pathuni") })
+	for _, want := range []string{
+		"  [R] /tmp/pathuni/corp/bin (required, not found)\n",
+		"  [R] /tmp/pathuni/work/bin (required, not found)\n",
+		"  [!] /tmp/pathuni/optional/bin (not found)\n",
+		"2 Required paths missing: /tmp/pathuni/corp/bin, /tmp/pathuni/work/bin\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry-run missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestRequired_StrictStopsWrites runs export and snapshot save with --strict
+// in a child process, since a missing required path exits.
+func TestRequired_StrictStopsWrites(t *testing.T) {
+	if cmd := os.Getenv("PATHUNI_TEST_STRICT_CMD"); cmd != "" {
+		setupTestFilesystem(t)
+		config = os.Getenv("PATHUNI_TEST_STRICT_CONFIG")
+		osOverride, shell, scope, prune, strict = "Linux", "bash", "pathuni", "pathuni", true
+		var err error
+		if cmd == "export" {
+			exportTarget, exportWrite = "environment.d", true
+			err = runExport()
+		} else {
+			err = runSnapshotSave(nil)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	cfgPath := filepath.Join(t.TempDir(), "required.yaml")
+	writeFile(t, cfgPath, requiredTestConfig)
+	home := t.TempDir()
+	for _, name := range []string{"export", "snapshot"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRequired_StrictStopsWrites$")
+		cmd.Env = append(os.Environ(),
+			"PATHUNI_TEST_STRICT_CMD="+name,
+			"PATHUNI_TEST_STRICT_CONFIG="+cfgPath,
+			"XDG_CONFIG_HOME="+filepath.Join(home, "config"),
+			"XDG_STATE_HOME="+filepath.Join(home, "state"),
+		)
+		out, err := cmd.CombinedOutput()
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok || exitErr.ExitCode() != exitRequiredMissing {
+			t.Errorf("%s: expected exit code %d, got %v\n%s", name, exitRequiredMissing, err, out)
+		}
+		if !strings.Contains(string(out), requiredWarning("/tmp/pathuni/corp/bin")) {
+			t.Errorf("%s: expected a required warning, got:\n%s", name, out)
+		}
+	}
+	if entries, _ := os.ReadDir(home); len(entries) > 0 {
+		t.Errorf("nothing should be written under --strict, found %v", entries)
+	}
+}
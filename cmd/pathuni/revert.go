@@ -0,0 +1,153 @@
+package main
+
+// Reverting init. With --with-revert, init also exports the entries it added
+// to the PATH (PATHUNI_ADDED) and the PATH from before the first init
+// (PATHUNI_ORIGINAL_PATH). Later inits measure PATHUNI_ADDED against that
+// original PATH, so evaluating init again still records its entries.
+// `pathuni unload` then removes exactly those entries from the live PATH,
+// leaving anything added since and anything on the original PATH untouched.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	revertAddedVar    = "PATHUNI_ADDED"
+	revertOriginalVar = "PATHUNI_ORIGINAL_PATH"
+)
+
+var withRevert bool
+
+// renderersRevert render the revert variables, keyed by shell. They run
+// before the PATH is changed so the original PATH can be recorded; an
+// original PATH recorded by an earlier init is kept.
+var renderersRevert = map[string]func(added []string) string{
+	"bash":       renderBashRevert,
+	"zsh":        renderBashRevert,
+	"sh":         renderBashRevert,
+	"dash":       renderBashRevert,
+	"ash":        renderBashRevert,
+	"ksh":        renderBashRevert,
+	"mksh":       renderBashRevert,
+	"yash":       renderBashRevert,
+	"fish":       renderFishRevert,
+	"powershell": renderPwshRevert,
+}
+
+func renderBashRevert(added []string) string {
+	return fmt.Sprintf("[ -n \"${%[1]s+x}\" ] || export %[1]s=\"${PATH}\"\nexport %[2]s=%[3]s",
+		revertOriginalVar, revertAddedVar, shQuote(strings.Join(added, ":")))
+}
+
+func renderFishRevert(added []string) string {
+	// Variables ending in PATH are lists in fish, exported joined with ':'
+	return fmt.Sprintf("set -q %[1]s; or set -gx %[1]s $PATH; set -gx %[2]s %[3]s",
+		revertOriginalVar, revertAddedVar, fishQuote(strings.Join(added, ":")))
+}
+
+func renderPwshRevert(added []string) string {
+	return fmt.Sprintf("if ($null -eq $env:%[1]s) { $env:%[1]s = $env:PATH }\n$env:%[2]s = %[3]s",
+		revertOriginalVar, revertAddedVar, pwshQuote(strings.Join(added, ":")))
+}
+
+// withRevertCode prepends the revert variables to init output for a shell.
+func withRevertCode(shellName, code string, added []string) string {
+	sep := "\n"
+	if shellName == "fish" {
+		// fish evaluates `eval (pathuni init)` as a single line
+		sep = "; "
+	}
+	return renderersRevert[shellName](added) + sep + code
+}
+
+// revertBaseline returns the PATH added entries are measured against: the
+// original PATH recorded by an earlier init, or the live PATH before the first.
+// Measuring against the live PATH again would record nothing on a second init.
+func revertBaseline() []string {
+	if original, ok := os.LookupEnv(revertOriginalVar); ok {
+		return filepath.SplitList(original)
+	}
+	return filepath.SplitList(os.Getenv("PATH"))
+}
+
+// addedEntries returns the entries of paths not already in the baseline PATH.
+func addedEntries(paths []string) []string {
+	present := make(map[string]bool)
+	for _, p := range revertBaseline() {
+		present[dedupeKey(p)] = true
+	}
+	var added []string
+	for _, p := range paths {
+		if key := dedupeKey(p); !present[key] {
+			present[key] = true
+			added = append(added, p)
+		}
+	}
+	return added
+}
+
+// unloadPath removes one occurrence of each added entry from path.
+func unloadPath(path, added []string) []string {
+	pending := make(map[string]int)
+	for _, p := range added {
+		pending[p]++
+	}
+	var kept []string
+	for _, p := range path {
+		if pending[p] > 0 {
+			pending[p]--
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+func runUnload() error {
+	shellName, _ := getShellName()
+	if !shellIsValid(shellName) {
+		return fmt.Errorf("unsupported shell '%s'. Supported shells: %s%s", shellName, strings.Join(shellNames(), ", "), configOnlyNote(shellName))
+	}
+	added, ok := os.LookupEnv(revertAddedVar)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "pathuni: nothing to unload (%s is not set; use 'init --with-revert')\n", revertAddedVar)
+		return nil
+	}
+	// Entries that were on the original PATH stay, whatever PATHUNI_ADDED says
+	original := make(map[string]bool)
+	if value, ok := os.LookupEnv(revertOriginalVar); ok {
+		for _, p := range filepath.SplitList(value) {
+			original[p] = true
+		}
+	}
+	var remove []string
+	for _, p := range filepath.SplitList(added) {
+		if !original[p] {
+			remove = append(remove, p)
+		}
+	}
+	path := unloadPath(filepath.SplitList(os.Getenv("PATH")), remove)
+	fmt.Println(renderHookUpdate(shellName, hookUpdate{
+		Path: path,
+		Vars: [][2]string{{revertAddedVar, ""}, {revertOriginalVar, ""}},
+	}))
+	return nil
+}
+
+var unloadCmd = &cobra.Command{
+	Use:         "unload",
+	Short:       "Print shell code removing the entries added by init --with-revert",
+	Args:        cobra.NoArgs,
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runUnload(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// evalSh evaluates code under sh with the given PATH and environment, then
+// prints PATH, PATHUNI_ADDED and PATHUNI_ORIGINAL_PATH on separate lines.
+func evalSh(t *testing.T, path, code string, env ...string) []string {
+	t.Helper()
+	script := code + "\nprintf '%s\\n%s\\n%s\\n' \"$PATH\" \"${PATHUNI_ADDED-unset}\" \"${PATHUNI_ORIGINAL_PATH-unset}\""
+	cmd := exec.Command("/bin/sh", "-c", script)
+	cmd.Env = append([]string{"PATH=" + path}, env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("sh: %v\n%s", err, out)
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+func TestRevert_InitAndUnload(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	setupTestFilesystem(t)
+
+	config = filepath.Join(t.TempDir(), "revert.yaml")
+	writeFile(t, config, "all:\n  paths:\n    - /tmp/pathuni/usr/local/bin\n    - /tmp/pathuni/bin\n")
+	osOverride, shell, scope, prune = "Linux", "bash", "full", "pathuni"
+	t.Cleanup(func() { config, osOverride, shell, deferEnv, withRevert = "", "", "", false, false })
+
+	live := "/tmp/pathuni/bin:/tmp/pathuni/usr/bin"
+	t.Setenv("PATH", live)
+	withRevert = true
+	for _, d := range []bool{false, true} {
+		deferEnv = d
+		code := captureOutput(func() { runInit() })
+
+		// Entries already on the PATH are not recorded as added
+		got := evalSh(t, live, code)
+		rest, ok := strings.CutPrefix(got[0], "/tmp/pathuni/usr/local/bin:")
+		if !ok || !strings.HasSuffix(rest, live) || got[1] != "/tmp/pathuni/usr/local/bin" || got[2] != live {
+			t.Fatalf("defer=%v: unexpected init result %q", d, got)
+		}
+
+		// Re-running init keeps the first original PATH
+		if again := evalSh(t, live, code+"\n"+code); again[2] != live {
+			t.Errorf("defer=%v: original PATH overwritten: %q", d, again[2])
+		}
+
+		// An entry added after init survives unload
+		t.Setenv("PATH", "/tmp/pathuni/home/Pratt/bin:"+got[0])
+		t.Setenv(revertAddedVar, got[1])
+		unload := captureOutput(func() {
+			if err := runUnload(); err != nil {
+				t.Errorf("unload: %v", err)
+			}
+		})
+		after := evalSh(t, "", unload, revertAddedVar+"="+got[1], revertOriginalVar+"="+live)
+		if want := []string{"/tmp/pathuni/home/Pratt/bin:" + rest, "unset", "unset"}; strings.Join(after, "|") != strings.Join(want, "|") {
+			t.Errorf("defer=%v: unload gave %q, want %q", d, after, want)
+		}
+		t.Setenv("PATH", live)
+	}
+}
+
+func TestRevert_Renderers(t *testing.T) {
+	added := []string{"/a b", "/c"}
+	tests := map[string]string{
+		"bash":       "[ -n \"${PATHUNI_ORIGINAL_PATH+x}\" ] || export PATHUNI_ORIGINAL_PATH=\"${PATH}\"\nexport PATHUNI_ADDED='/a b:/c'",
+		"fish":       "set -q PATHUNI_ORIGINAL_PATH; or set -gx PATHUNI_ORIGINAL_PATH $PATH; set -gx PATHUNI_ADDED '/a b:/c'",
+		"powershell": "if ($null -eq $env:PATHUNI_ORIGINAL_PATH) { $env:PATHUNI_ORIGINAL_PATH = $env:PATH }\n$env:PATHUNI_ADDED = '/a b:/c'",
+	}
+	for shellName, want := range tests {
+		if got := renderersRevert[shellName](added); got != want {
+			t.Errorf("%s revert render = %q, want %q", shellName, got, want)
+		}
+	}
+	for name := range renderers {
+		if renderersRevert[name] == nil {
+			t.Errorf("no revert renderer for %s", name)
+		}
+	}
+
+	if got := unloadPath([]string{"/u", "/a", "/x", "/a"}, []string{"/a"}); strings.Join(got, ":") != "/u:/x:/a" {
+		t.Errorf("unload should remove one occurrence per added entry, got %v", got)
+	}
+}
+
+func TestRevert_InitTwiceThenUnload(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	setupTestFilesystem(t)
+
+	config = filepath.Join(t.TempDir(), "revert.yaml")
+	writeFile(t, config, "all:\n  paths:\n    - /tmp/pathuni/usr/local/bin\n    - /tmp/pathuni/bin\n")
+	osOverride, shell, scope, prune = "Linux", "bash", "full", "pathuni"
+	t.Cleanup(func() { config, osOverride, shell, deferEnv, withRevert = "", "", "", false, false })
+
+	live := "/tmp/pathuni/bin:/tmp/pathuni/usr/bin"
+	withRevert = true
+	for _, d := range []bool{true, false} {
+		deferEnv = d
+		os.Unsetenv(revertAddedVar)
+		os.Unsetenv(revertOriginalVar)
+		t.Setenv("PATH", live)
+
+		// Each init is generated from the environment the previous one left
+		state := []string{live, "unset", "unset"}
+		for i := 0; i < 2; i++ {
+			code := captureOutput(func() { runInit() })
+			env := []string{}
+			if state[1] != "unset" {
+				env = append(env, revertAddedVar+"="+state[1], revertOriginalVar+"="+state[2])
+			}
+			state = evalSh(t, state[0], code, env...)
+			t.Setenv("PATH", state[0])
+			t.Setenv(revertAddedVar, state[1])
+			t.Setenv(revertOriginalVar, state[2])
+		}
+		if state[1] != "/tmp/pathuni/usr/local/bin" || state[2] != live {
+			t.Fatalf("defer=%v: second init recorded %q", d, state)
+		}
+
+		unload := captureOutput(func() {
+			if err := runUnload(); err != nil {
+				t.Errorf("unload: %v", err)
+			}
+		})
+		after := evalSh(t, "", unload, revertAddedVar+"="+state[1], revertOriginalVar+"="+state[2])
+		if strings.Contains(after[0], "/tmp/pathuni/usr/local/bin") || !strings.Contains(after[0], "/tmp/pathuni/bin") {
+			t.Errorf("defer=%v: unload after two inits left PATH at %q", d, after[0])
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+// Target filesystem. Existence checks and system file reads go through an
+// fs.FS rooted at --root (default "/"), so a PATH can be computed for a
+// container image or the mounted disk of another machine. Paths are checked
+// under the root but emitted unprefixed. Symlinks are resolved inside the
+// root, as after chroot: an absolute link in an image points into the image,
+// not at the host.
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// rootDir is the directory standing in for "/" (--root).
+var rootDir string
+
+// rootFS replaces the filesystem built from rootDir when set; tests use an
+// fstest.MapFS.
+var rootFS fs.FS
+
+// maxSymlinks bounds symlink resolution, like the kernel's ELOOP limit.
+const maxSymlinks = 40
+
+var errSymlinkLoop = errors.New("too many levels of symbolic links")
+
+// baseFS returns the filesystem standing in for "/", without symlink
+// resolution.
+func baseFS() fs.FS {
+	if rootFS != nil {
+		return rootFS
+	}
+	if rootDir == "" {
+		return os.DirFS("/")
+	}
+	return os.DirFS(rootDir)
+}
+
+// targetFS returns the filesystem paths are checked against.
+func targetFS() fs.FS {
+	if rootFS == nil && rootDir == "" {
+		return os.DirFS("/")
+	}
+	return rootedFS{baseFS()}
+}
+
+// rootedFS resolves symlinks in names itself so they stay inside fsys;
+// os.DirFS would let the host follow absolute targets out of the root.
+type rootedFS struct {
+	fsys fs.FS
+}
+
+func (r rootedFS) Open(name string) (fs.File, error) {
+	resolved, err := resolveInRoot(r.fsys, name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return r.fsys.Open(resolved)
+}
+
+func (r rootedFS) Stat(name string) (fs.FileInfo, error) {
+	resolved, err := resolveInRoot(r.fsys, name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return fs.Stat(r.fsys, resolved)
+}
+
+func (r rootedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, err := resolveInRoot(r.fsys, name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return fs.ReadDir(r.fsys, resolved)
+}
+
+func (r rootedFS) Lstat(name string) (fs.FileInfo, error) {
+	resolved, err := resolveInRoot(r.fsys, name, false)
+	if err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return fs.Lstat(r.fsys, resolved)
+}
+
+func (r rootedFS) ReadLink(name string) (string, error) {
+	resolved, err := resolveInRoot(r.fsys, name, false)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return fs.ReadLink(r.fsys, resolved)
+}
+
+// resolveInRoot returns name with its symlinks resolved inside fsys. Absolute
+// targets restart at the root and ".." stops there. The last component is
+// only followed when followLast is set. Missing components are kept as they
+// are, leaving the caller's operation to report them.
+func resolveInRoot(fsys fs.FS, name string, followLast bool) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", fs.ErrInvalid
+	}
+	var resolved []string
+	pending := strings.Split(name, "/")
+	links := 0
+	for len(pending) > 0 {
+		part := pending[0]
+		pending = pending[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) > 0 {
+				resolved = resolved[:len(resolved)-1]
+			}
+			continue
+		}
+		current := path.Join(path.Join(resolved...), part)
+		if len(pending) == 0 && !followLast {
+			resolved = append(resolved, part)
+			continue
+		}
+		info, err := fs.Lstat(fsys, current)
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+		if links++; links > maxSymlinks {
+			return "", errSymlinkLoop
+		}
+		target, err := fs.ReadLink(fsys, current)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(target, "/") {
+			resolved = nil
+		}
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+	if len(resolved) == 0 {
+		return ".", nil
+	}
+	return path.Join(resolved...), nil
+}
+
+// validateRoot checks that --root names a directory.
+func validateRoot() error {
+	if rootDir == "" {
+		return nil
+	}
+	info, err := os.Stat(rootDir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("--root %s is not a directory", rootDir)
+	}
+	return nil
+}
+
+// fsName converts a path into a name in the target filesystem. Relative
+// paths are resolved against the working directory first.
+func fsName(path string) string {
+	if !filepath.IsAbs(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// realPathIn is filepath.EvalSymlinks on the target filesystem fsys: the
+// absolute path of an existing entry with every symlink resolved inside the
+// root.
+func realPathIn(fsys fs.FS, p string) (string, error) {
+	if r, ok := fsys.(rootedFS); ok {
+		fsys = r.fsys
+	}
+	resolved, err := resolveInRoot(fsys, fsName(p), true)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fs.Stat(fsys, resolved); err != nil {
+		return "", err
+	}
+	if resolved == "." {
+		return "/", nil
+	}
+	return "/" + resolved, nil
+}
+
+// lstatPath is os.Lstat on the target filesystem.
+func lstatPath(path string) (fs.FileInfo, error) {
+	return fs.Lstat(targetFS(), fsName(path))
+}
+
+// openPath is os.Open on the target filesystem.
+func openPath(path string) (fs.File, error) {
+	return targetFS().Open(fsName(path))
+}
+
+// readDirPath is os.ReadDir on the target filesystem.
+func readDirPath(path string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(targetFS(), fsName(path))
+}
+
+// globPath is filepath.Glob on the target filesystem. Matches are absolute
+// paths without the root.
+func globPath(pattern string) []string {
+	matches, _ := fs.Glob(targetFS(), fsName(pattern))
+	for i, m := range matches {
+		matches[i] = "/" + m
+	}
+	return matches
+}
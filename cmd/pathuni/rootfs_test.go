@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// withTestRoot evaluates against an in-memory filesystem holding the files
+// under the testdata directory dir, added to the directories set up by
+// setupTestFilesystem if it was called first.
+func withTestRoot(t *testing.T, dir string) fstest.MapFS {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	if current, ok := rootFS.(fstest.MapFS); ok {
+		maps.Copy(fsys, current)
+	}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		rel, _ := filepath.Rel(dir, path)
+		fsys[filepath.ToSlash(rel)] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	withRootFS(t, fsys)
+	return fsys
+}
+
+func withRootFS(t *testing.T, fsys fs.FS) {
+	t.Helper()
+	old := rootFS
+	rootFS = fsys
+	t.Cleanup(func() { rootFS = old })
+}
+
+func TestRoot_ChecksUnderRootEmitsUnprefixed(t *testing.T) {
+	withRootFS(t, fstest.MapFS{
+		"opt/target/bin":     {Mode: fs.ModeDir | 0755},
+		"usr/local/bin":      {Mode: fs.ModeDir | 0755},
+		"opt/file":           {Data: []byte("not a directory")},
+		"etc/paths":          {Data: []byte("/usr/local/bin\n/usr/bin\n")},
+		"etc/paths.d/target": {Data: []byte("/opt/target/bin\n")},
+	})
+	t.Setenv("PATH", "/host/bin")
+
+	cfgPath := filepath.Join(t.TempDir(), "root.yaml")
+	writeFile(t, cfgPath, `macos:
+  paths:
+    - /opt/target/bin
+    - /opt/host-only/bin
+    - /opt/file
+  powershell:
+    include_system_paths: true
+`)
+	statuses, _, err := EvaluateConfigDetailed(cfgPath, "macOS", "bash", TagFilter{})
+	if err != nil {
+		t.Fatalf("EvaluateConfigDetailed: %v", err)
+	}
+	exists := map[string]bool{}
+	for _, st := range statuses {
+		exists[st.Path] = st.Exists
+	}
+	if !exists["/opt/target/bin"] || exists["/opt/host-only/bin"] || exists["/opt/file"] {
+		t.Errorf("existence must be checked under the root, got %v", exists)
+	}
+
+	// System files come from the root as well
+	sys, err := resolveSystemPathsContext(cfgPath, "macOS", "powershell")
+	if err != nil {
+		t.Fatalf("resolveSystemPathsContext: %v", err)
+	}
+	if got := strings.Join(sys, ":"); got != "/host/bin:/usr/local/bin:/usr/bin:/opt/target/bin" {
+		t.Errorf("unexpected system paths %s", got)
+	}
+	if got := filterExisting(sys); strings.Join(got, ":") != "/usr/local/bin:/opt/target/bin" {
+		t.Errorf("unexpected existing system paths %v", got)
+	}
+}
+
+func TestRoot_Directory(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "opt", "image", "bin"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	rootDir = root
+	t.Cleanup(func() { rootDir = "" })
+
+	if !dirExists("/opt/image/bin") || dirExists(root) {
+		t.Errorf("paths must resolve under --root %s", root)
+	}
+	if err := validateRoot(); err != nil {
+		t.Errorf("validateRoot: %v", err)
+	}
+	rootDir = filepath.Join(root, "missing")
+	if err := validateRoot(); err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("expected invalid root error, got %v", err)
+	}
+}
+
+func TestRoot_SymlinksStayInsideRoot(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"usr/local/tool/bin", "opt"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	host := t.TempDir()
+	links := map[string]string{
+		"opt/tool":    "/usr/local/tool",    // absolute, into the image
+		"opt/up":      "../../../usr/local", // ".." stops at the root
+		"opt/host":    host,                 // exists on the host only
+		"opt/loop":    "/opt/loop",
+		"usr/sdk-bin": "local/tool/bin",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatalf("symlink: %v", err)
+		}
+	}
+	rootDir = root
+	t.Cleanup(func() { rootDir = "" })
+
+	for path, want := range map[string]bool{
+		"/opt/tool/bin":  true,
+		"/opt/up/tool":   true,
+		"/usr/sdk-bin":   true,
+		"/opt/host":      false,
+		"/opt/loop/bin":  false,
+		"/opt/tool/none": false,
+	} {
+		if got := dirExists(path); got != want {
+			t.Errorf("dirExists(%s) = %v, want %v", path, got, want)
+		}
+	}
+	if real, err := realPathIn(targetFS(), "/opt/tool/bin"); err != nil || real != "/usr/local/tool/bin" {
+		t.Errorf("realPath = %q, %v", real, err)
+	}
+	if info, err := lstatPath("/opt/tool"); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("lstat should not follow the last link: %v, %v", info, err)
+	}
+}
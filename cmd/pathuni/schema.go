@@ -0,0 +1,221 @@
+package main
+
+// JSON Schema for the config format, generated from the Go types so editors
+// (VS Code YAML, JetBrains) can validate and autocomplete configs. Fields the
+// plain type mapping cannot express (the string-or-object path union, tag
+// patterns, enums) are described in schemaFieldOverrides.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaObject is a JSON Schema fragment. Keys are sorted when marshalled, so
+// the output is stable.
+type schemaObject map[string]interface{}
+
+// schemaField overrides the schema of a Go field; Name is only needed for
+// fields hidden from yaml (`yaml:"-"`) that are parsed by hand.
+type schemaField struct {
+	Name   string
+	Schema func() schemaObject
+}
+
+func tagSchema() schemaObject {
+	return schemaObject{
+		"type": "string",
+		"anyOf": []schemaObject{
+			{"pattern": tagRegex.String(), "description": "Tag: 3-20 characters, starting with a letter; letters, digits and underscores"},
+			{"pattern": `[*?\[\]]`, "description": "Wildcard pattern using *, ? and [...]"},
+		},
+	}
+}
+
+func tagListSchema(description string) func() schemaObject {
+	return func() schemaObject {
+		return schemaObject{"type": "array", "items": tagSchema(), "description": description}
+	}
+}
+
+func enumSchema(values ...string) func() schemaObject {
+	return func() schemaObject {
+		return schemaObject{"type": "string", "enum": values}
+	}
+}
+
+// pathListSchema describes a paths list: strings or PathEntry objects.
+func pathListSchema() schemaObject {
+	return schemaObject{
+		"type": "array",
+		"items": schemaObject{"oneOf": []schemaObject{
+			{"type": "string", "minLength": 1, "description": "Path, environment variables are expanded"},
+			{"$ref": "#/$defs/PathEntry"},
+		}},
+	}
+}
+
+// schemaFieldOverrides is keyed by "Type.Field".
+var schemaFieldOverrides = map[string]schemaField{
+	"PlatformConfig.Tags":  {Schema: tagListSchema("Tags inherited by paths without a tags field")},
+	"PlatformConfig.Paths": {Schema: pathListSchema},
+	"PlatformConfig.Shells": {Schema: func() schemaObject {
+		return schemaObject{
+			"type":                 "object",
+			"propertyNames":        schemaObject{"enum": configShellNames()},
+			"additionalProperties": schemaObject{"$ref": "#/$defs/ShellConfig"},
+			"description":          "Per-shell settings, keyed by shell name",
+		}
+	}},
+	"PlatformConfig.Providers": {Schema: func() schemaObject {
+		names := providerNames()
+		for alias := range providerAliases {
+			names = append(names, alias)
+		}
+		sort.Strings(names)
+		return schemaObject{"type": "array", "items": schemaObject{"type": "string", "enum": names}}
+	}},
+	"PlatformConfig.Remove": {Schema: func() schemaObject {
+		return schemaObject{
+			"type":        "array",
+			"items":       schemaObject{"type": "string", "minLength": 1},
+			"description": "Exact paths or globs dropped from the system PATH",
+		}
+	}},
+	"PlatformConfig.SystemSource":      {Schema: enumSchema("env", "login_shell")},
+	"ShellConfig.Paths":                {Schema: pathListSchema},
+	"ShellConfig.Tags":                 {Schema: tagListSchema("Tags for the system paths included for this shell")},
+	"ShellConfig.IncludeSystemPathsAs": {Schema: enumSchema("system", "pathuni")},
+	"ShellConfig.SystemSources": {Schema: func() schemaObject {
+		return schemaObject{"type": "array", "items": schemaObject{"type": "string", "enum": systemSourceOrder}}
+	}},
+	"PathEntry.Tags": {Schema: tagListSchema("Tags for this path; an explicit list (even empty) replaces the inherited platform tags")},
+	"PathEntry.Command": {Schema: func() schemaObject {
+		return schemaObject{"type": "array", "minItems": 1, "items": schemaObject{"type": "string"}}
+	}},
+	"PathEntry.Position": {Name: "position", Schema: func() schemaObject {
+		anchor := func(key string) schemaObject {
+			return schemaObject{
+				"type":                 "object",
+				"properties":           schemaObject{key: schemaObject{"type": "string", "minLength": 1}},
+				"required":             []string{key},
+				"additionalProperties": false,
+			}
+		}
+		return schemaObject{"oneOf": []schemaObject{
+			{"type": "string", "enum": []string{"prepend", "append"}},
+			anchor("before"),
+			anchor("after"),
+		}}
+	}},
+	"Config.Vars": {Schema: func() schemaObject {
+		return schemaObject{
+			"type":                 "object",
+			"propertyNames":        schemaObject{"pattern": varNameRegex.String()},
+			"additionalProperties": schemaObject{"type": "string"},
+			"description":          "Variables usable as $name or ${name} in paths; they take precedence over environment variables",
+		}
+	}},
+	"Profile.Scope":  {Schema: enumSchema("system", "pathuni", "full")},
+	"Profile.Prune":  {Schema: enumSchema("none", "pathuni", "system", "all")},
+	"Profile.Dedupe": {Schema: enumSchema("exact", "clean", "realpath")},
+}
+
+// schemaTypeExtras adds constraints spanning several fields of a type.
+var schemaTypeExtras = map[string]schemaObject{
+	"PathEntry": {
+		"oneOf":             []schemaObject{{"required": []string{"path"}}, {"required": []string{"command"}}},
+		"dependentRequired": schemaObject{"suffix": []string{"command"}},
+	},
+}
+
+// schemaFieldName returns the config key of a struct field, or "" when the
+// field is not part of the config format.
+func schemaFieldName(t reflect.Type, f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return schemaFieldOverrides[t.Name()+"."+f.Name].Name
+	}
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// typeSchema maps a Go type to a schema, registering structs under defs.
+func typeSchema(t reflect.Type, defs schemaObject) schemaObject {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return schemaObject{"type": "string"}
+	case reflect.Bool:
+		return schemaObject{"type": "boolean"}
+	case reflect.Int:
+		return schemaObject{"type": "integer"}
+	case reflect.Slice:
+		return schemaObject{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return schemaObject{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, done := defs[t.Name()]; !done {
+			defs[t.Name()] = nil // reserve to stop recursion
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return schemaObject{"$ref": "#/$defs/" + t.Name()}
+	}
+	return schemaObject{}
+}
+
+func structSchema(t reflect.Type, defs schemaObject) schemaObject {
+	props := schemaObject{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := schemaFieldName(t, f)
+		if name == "" {
+			continue
+		}
+		if override, ok := schemaFieldOverrides[t.Name()+"."+f.Name]; ok {
+			props[name] = override.Schema()
+		} else {
+			props[name] = typeSchema(f.Type, defs)
+		}
+	}
+	s := schemaObject{"type": "object", "properties": props, "additionalProperties": false}
+	for k, v := range schemaTypeExtras[t.Name()] {
+		s[k] = v
+	}
+	return s
+}
+
+// generateConfigSchema returns the JSON Schema of the config format.
+func generateConfigSchema() schemaObject {
+	defs := schemaObject{}
+	root := structSchema(reflect.TypeOf(Config{}), defs)
+	// Paths are []interface{} in Go; their object form is referenced by the
+	// PlatformConfig.Paths override.
+	typeSchema(reflect.TypeOf(PathEntry{}), defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "pathuni config"
+	root["$defs"] = defs
+	return root
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:         "schema",
+	Short:       "Print the JSON Schema of the config format",
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := json.MarshalIndent(generateConfigSchema(), "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	},
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"testing"
+)
+
+const schemaFile = "../../schema/pathuni.schema.json"
+
+func schemaKeys(props interface{}) []string {
+	var keys []string
+	for k := range props.(schemaObject) {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func nodeSchemaKeys(s *nodeSchema) []string {
+	var keys []string
+	for k := range s.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSchema_MatchesCommittedFile(t *testing.T) {
+	data, err := json.MarshalIndent(generateConfigSchema(), "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	committed, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("read %s: %v", schemaFile, err)
+	}
+	if string(committed) != string(data)+"\n" {
+		t.Errorf("%s is out of date; regenerate it with `pathuni config schema > schema/pathuni.schema.json`", schemaFile)
+	}
+}
+
+// The JSON Schema is generated from the Go types while strict checking uses
+// its own field lists; both must accept exactly the same keys.
+func TestSchema_MatchesStrictFields(t *testing.T) {
+	root := generateConfigSchema()
+	defs := root["$defs"].(schemaObject)
+
+	checks := []struct {
+		name   string
+		props  interface{}
+		strict *nodeSchema
+	}{
+		{"top level", root["properties"], configSchema},
+		{"PlatformConfig", defs["PlatformConfig"].(schemaObject)["properties"], platformSchema},
+		{"PathEntry", defs["PathEntry"].(schemaObject)["properties"], pathEntrySchema},
+		{"ShellConfig", defs["ShellConfig"].(schemaObject)["properties"], shellConfigSchema},
+		{"Profile", defs["Profile"].(schemaObject)["properties"], profileSchema},
+	}
+	for _, c := range checks {
+		got, want := schemaKeys(c.props), nodeSchemaKeys(c.strict)
+		if len(got) != len(want) {
+			t.Errorf("%s: schema fields %v, strict fields %v", c.name, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s: schema fields %v, strict fields %v", c.name, got, want)
+				break
+			}
+		}
+	}
+
+	// position is a string or a single-key {before|after} object
+	var anchors []string
+	position := defs["PathEntry"].(schemaObject)["properties"].(schemaObject)["position"].(schemaObject)
+	for _, alt := range position["oneOf"].([]schemaObject) {
+		if props, ok := alt["properties"]; ok {
+			anchors = append(anchors, schemaKeys(props)...)
+		}
+	}
+	sort.Strings(anchors)
+	if want := nodeSchemaKeys(positionSchema); len(anchors) != len(want) || anchors[0] != want[0] || anchors[1] != want[1] {
+		t.Errorf("position: schema anchors %v, strict fields %v", anchors, want)
+	}
+}
+
+func TestSchema_Constraints(t *testing.T) {
+	defs := generateConfigSchema()["$defs"].(schemaObject)
+	shellProps := defs["ShellConfig"].(schemaObject)["properties"].(schemaObject)
+	enum := shellProps["include_system_paths_as"].(schemaObject)["enum"].([]string)
+	if len(enum) != 2 || enum[0] != "system" || enum[1] != "pathuni" {
+		t.Errorf("unexpected include_system_paths_as enum %v", enum)
+	}
+
+	// Every shell the config accepts under shells:, and only those
+	shellKeys := defs["PlatformConfig"].(schemaObject)["properties"].(schemaObject)["shells"].(schemaObject)["propertyNames"].(schemaObject)["enum"].([]string)
+	inEnum := make(map[string]bool)
+	for _, name := range shellKeys {
+		inEnum[name] = true
+	}
+	for _, name := range append(append(configShellNames(), shellKeys...), "tcsh", "nushell") {
+		err := validateShellConfigs(PlatformConfig{Shells: map[string]*ShellConfig{name: {}}}, "all")
+		if accepted := err == nil; accepted != inEnum[name] {
+			t.Errorf("shell %q: accepted by the config = %v, in the schema enum = %v", name, accepted, inEnum[name])
+		}
+	}
+
+	tags := defs["PathEntry"].(schemaObject)["properties"].(schemaObject)["tags"].(schemaObject)
+	tagAlts := tags["items"].(schemaObject)["anyOf"].([]schemaObject)
+	if tagAlts[0]["pattern"] != tagRegex.String() {
+		t.Errorf("tag pattern %v does not match tagRegex %s", tagAlts[0]["pattern"], tagRegex)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+// Layered resolution of the global flags. Each setting is taken from the first
+// layer that provides it: command-line flag, PATHUNI_* environment variable,
+// active profile, config `defaults:` section, then the built-in default.
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// setting is a global flag that can also be set from the environment and,
+// when Config is non-nil, from a profile or the config defaults.
+type setting struct {
+	Flag   string
+	Config func(Profile) (string, bool)
+}
+
+// settings lists the layered global flags, in display order.
+var settings = []setting{
+	{Flag: "config"},
+	{Flag: "profile"},
+	{Flag: "shell"},
+	{Flag: "os"},
+	{Flag: "tags-include", Config: func(p Profile) (string, bool) { return p.TagsInclude, p.TagsInclude != "" }},
+	{Flag: "tags-exclude", Config: func(p Profile) (string, bool) { return p.TagsExclude, p.TagsExclude != "" }},
+	{Flag: "scope", Config: func(p Profile) (string, bool) { return p.Scope, p.Scope != "" }},
+	{Flag: "prune", Config: func(p Profile) (string, bool) { return p.Prune, p.Prune != "" }},
+	{Flag: "dedupe", Config: func(p Profile) (string, bool) { return p.Dedupe, p.Dedupe != "" }},
+	{Flag: "defer-env", Config: func(p Profile) (string, bool) {
+		if p.DeferEnv == nil {
+			return "", false
+		}
+		return fmt.Sprintf("%t", *p.DeferEnv), true
+	}},
+	{Flag: "lenient"},
+	{Flag: "strict"},
+	{Flag: "auto-snapshot"},
+	{Flag: "with-revert"},
+	{Flag: "root"},
+	{Flag: "stat-timeout"},
+	{Flag: "stat-timeout-policy"},
+}
+
+// settingSources records where each effective setting came from: "flag",
+// "env", "profile", "config" or "default".
+var settingSources map[string]string
+
+// skipConfigLayers annotates commands that do not evaluate the config. They
+// only take settings from flags and the environment, so a broken config file
+// cannot stop the commands used to inspect or repair it.
+var skipConfigLayers = map[string]string{"pathuni/config-layers": "skip"}
+
+// settingEnvVar returns the environment variable backing a flag, e.g.
+// PATHUNI_TAGS_INCLUDE for --tags-include.
+func settingEnvVar(flag string) string {
+	return "PATHUNI_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// resolveSettings fills every layered flag that was not passed on the command
+// line from the lower layers, updating the flag globals and settingSources.
+// Values are applied through the flag set so they are parsed exactly like
+// command-line values, without marking the flags as changed.
+func resolveSettings(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	settingSources = make(map[string]string)
+	activeProfile, activeProfileSource = "", ""
+
+	// Flags and environment come first: the config path and profile must be
+	// known before the config layers can be read.
+	for _, s := range settings {
+		if flags.Changed(s.Flag) {
+			settingSources[s.Flag] = "flag"
+			continue
+		}
+		envVar := settingEnvVar(s.Flag)
+		if value := os.Getenv(envVar); value != "" {
+			if err := flags.Lookup(s.Flag).Value.Set(value); err != nil {
+				return fmt.Errorf("invalid %s: %v", envVar, err)
+			}
+			settingSources[s.Flag] = "env"
+		}
+	}
+	if source, ok := settingSources["profile"]; ok && profileName != "" {
+		activeProfile, activeProfileSource = profileName, source
+	}
+
+	var defaults, profile Profile
+	if cmd.Annotations["pathuni/config-layers"] != "skip" {
+		var err error
+		if defaults, profile, err = configLayers(getConfigPath(), activeProfile); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range settings {
+		if _, resolved := settingSources[s.Flag]; resolved {
+			continue
+		}
+		settingSources[s.Flag] = "default"
+		if s.Config == nil {
+			continue
+		}
+		for _, layer := range []struct {
+			source  string
+			profile Profile
+		}{{"profile", profile}, {"config", defaults}} {
+			value, ok := s.Config(layer.profile)
+			if !ok {
+				continue
+			}
+			if err := flags.Lookup(s.Flag).Value.Set(value); err != nil {
+				return fmt.Errorf("invalid %s from %s: %v", s.Flag, layer.source, err)
+			}
+			settingSources[s.Flag] = layer.source
+			break
+		}
+	}
+	return nil
+}
+
+// configLayers reads the config defaults and the resolved active profile. A
+// missing config file is not an error unless a profile was requested.
+func configLayers(configPath, profile string) (defaults Profile, active Profile, err error) {
+	if !configExists(configPath) && profile == "" {
+		return Profile{}, Profile{}, nil
+	}
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return Profile{}, Profile{}, err
+	}
+	if profile != "" {
+		if active, _, err = resolveProfile(cfg.Profiles, profile); err != nil {
+			return Profile{}, Profile{}, err
+		}
+	}
+	return cfg.Defaults, active, nil
+}
+
+// formatSettingSources renders settingSources for the dry-run header, leaving
+// out settings that kept their built-in default.
+func formatSettingSources() string {
+	parts := make([]string, 0, len(settings))
+	for _, s := range settings {
+		if source, ok := settingSources[s.Flag]; ok && source != "default" {
+			parts = append(parts, fmt.Sprintf("%s=%s", s.Flag, source))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// settingValue returns the effective value of a setting for display, resolving
+// detected values (config path, shell, OS) the same way the commands do.
+func settingValue(cmd *cobra.Command, flag string) (value string, detected bool) {
+	switch flag {
+	case "config":
+		return getConfigPath(), false
+	case "shell":
+		return getShellName()
+	case "os":
+		return getOSName()
+	case "profile":
+		if activeProfile == "" {
+			return "(none)", false
+		}
+		return activeProfile, false
+	}
+	return cmd.Flags().Lookup(flag).Value.String(), false
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect pathuni configuration",
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show each resolved setting and where it came from",
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigShow(cmd)
+	},
+}
+
+func runConfigShow(cmd *cobra.Command) {
+	for _, s := range settings {
+		value, detected := settingValue(cmd, s.Flag)
+		source := settingSources[s.Flag]
+		if source == "env" {
+			source = "env " + settingEnvVar(s.Flag)
+		} else if source == "default" && detected {
+			source = "detected"
+		}
+		fmt.Printf("%-13s %-40s (%s)\n", s.Flag, value, source)
+	}
+}
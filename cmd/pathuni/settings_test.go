@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newSettingsTestCmd returns a fresh command carrying the layered global
+// flags, bound to the same globals as rootCmd, so each test starts with no
+// flag marked as changed. The globals are reset again when the test ends.
+func newSettingsTestCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	reset := func() {
+		config, shell, osOverride, profileName = "", "", "", ""
+		tagsInclude, tagsExclude, scope, prune, dedupe, deferEnv = "", "", "full", "pathuni", "clean", false
+		activeProfile, activeProfileSource, settingSources, lenient, strict, autoSnapshot, withRevert, rootDir = "", "", nil, false, false, false, false, ""
+	}
+	reset()
+	t.Cleanup(reset)
+	cmd := &cobra.Command{Use: "pathuni"}
+	cmd.Flags().StringVarP(&config, "config", "c", "", "")
+	cmd.Flags().StringVarP(&shell, "shell", "S", "", "")
+	cmd.Flags().StringVarP(&osOverride, "os", "O", "", "")
+	cmd.Flags().StringVarP(&tagsInclude, "tags-include", "t", "", "")
+	cmd.Flags().StringVarP(&tagsExclude, "tags-exclude", "x", "", "")
+	cmd.Flags().StringVarP(&scope, "scope", "s", "full", "")
+	cmd.Flags().StringVarP(&prune, "prune", "p", "pathuni", "")
+	cmd.Flags().StringVar(&dedupe, "dedupe", "clean", "")
+	cmd.Flags().BoolVarP(&deferEnv, "defer-env", "d", false, "")
+	cmd.Flags().StringVarP(&profileName, "profile", "P", "", "")
+	cmd.Flags().BoolVar(&lenient, "lenient", false, "")
+	cmd.Flags().BoolVar(&strict, "strict", false, "")
+	cmd.Flags().BoolVar(&autoSnapshot, "auto-snapshot", false, "")
+	cmd.Flags().BoolVar(&withRevert, "with-revert", false, "")
+	cmd.Flags().StringVar(&rootDir, "root", "", "")
+	cmd.Flags().DurationVar(&statTimeout, "stat-timeout", 0, "")
+	cmd.Flags().StringVar(&statTimeoutPolicy, "stat-timeout-policy", "skip", "")
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	return cmd
+}
+
+func writeSettingsConfig(t *testing.T) string {
+	t.Helper()
+	cfgPath := filepath.Join(t.TempDir(), "settings.yaml")
+	cfgContent := `defaults:
+  scope: pathuni
+  prune: none
+  tags_exclude: gaming
+profiles:
+  work:
+    prune: all
+all:
+  paths:
+    - "/tmp/pathuni/usr/local/bin"
+`
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0644); err != nil {
+		t.Fatalf("write cfg: %v", err)
+	}
+	return cfgPath
+}
+
+func TestSettings_EnvVarNames(t *testing.T) {
+	for flag, want := range map[string]string{
+		"config":       "PATHUNI_CONFIG",
+		"tags-include": "PATHUNI_TAGS_INCLUDE",
+		"defer-env":    "PATHUNI_DEFER_ENV",
+	} {
+		if got := settingEnvVar(flag); got != want {
+			t.Errorf("settingEnvVar(%q) = %q, want %q", flag, got, want)
+		}
+	}
+}
+
+func TestSettings_Precedence(t *testing.T) {
+	t.Setenv("PATHUNI_CONFIG", writeSettingsConfig(t))
+	t.Setenv("PATHUNI_PROFILE", "work")
+	t.Setenv("PATHUNI_SHELL", "fish")
+	t.Setenv("PATHUNI_SCOPE", "system")
+	t.Setenv("PATHUNI_DEFER_ENV", "true")
+
+	cmd := newSettingsTestCmd(t, "--shell", "zsh")
+	if err := resolveSettings(cmd); err != nil {
+		t.Fatalf("resolveSettings: %v", err)
+	}
+
+	checks := []struct {
+		flag, value, source string
+		got                 string
+	}{
+		{"shell", "zsh", "flag", shell},
+		{"scope", "system", "env", scope},
+		{"prune", "all", "profile", prune},
+		{"tags-exclude", "gaming", "config", tagsExclude},
+		{"tags-include", "", "default", tagsInclude},
+		{"os", "", "default", osOverride},
+	}
+	for _, c := range checks {
+		if c.got != c.value || settingSources[c.flag] != c.source {
+			t.Errorf("%s = %q from %s, want %q from %s", c.flag, c.got, settingSources[c.flag], c.value, c.source)
+		}
+	}
+	if !deferEnv || settingSources["defer-env"] != "env" {
+		t.Errorf("expected defer-env=true from env, got %v from %s", deferEnv, settingSources["defer-env"])
+	}
+	if activeProfile != "work" || activeProfileSource != "env" {
+		t.Errorf("unexpected active profile %q from %q", activeProfile, activeProfileSource)
+	}
+
+	t.Setenv("PATHUNI_DEFER_ENV", "maybe")
+	err := resolveSettings(newSettingsTestCmd(t))
+	if err == nil || !strings.Contains(err.Error(), "invalid PATHUNI_DEFER_ENV") {
+		t.Errorf("expected invalid PATHUNI_DEFER_ENV error, got %v", err)
+	}
+}
+
+func TestSettings_MissingConfigWithoutProfile(t *testing.T) {
+	t.Setenv("PATHUNI_PROFILE", "")
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if err := resolveSettings(newSettingsTestCmd(t, "-c", missing)); err != nil {
+		t.Errorf("missing config should not fail without a profile: %v", err)
+	}
+	if err := resolveSettings(newSettingsTestCmd(t, "-c", missing, "-P", "work")); err == nil {
+		t.Error("expected error when a profile is requested from a missing config")
+	}
+}
+
+func TestSettings_ConfigShow(t *testing.T) {
+	t.Setenv("PATHUNI_PROFILE", "")
+	t.Setenv("PATHUNI_PRUNE", "system")
+	cfgPath := writeSettingsConfig(t)
+	cmd := newSettingsTestCmd(t, "-c", cfgPath, "-S", "bash", "-O", "linux")
+	if err := resolveSettings(cmd); err != nil {
+		t.Fatalf("resolveSettings: %v", err)
+	}
+
+	out := captureOutput(func() { runConfigShow(cmd) })
+	for _, want := range []string{
+		"config        " + cfgPath,
+		"profile       (none)",
+		"shell         bash",
+		"os            Linux",
+		"scope         pathuni                                  (config)\n",
+		"prune         system                                   (env PATHUNI_PRUNE)\n",
+		"defer-env     false                                    (default)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("config show missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestSettings_BrokenConfigOnlyStopsEvaluatingCommands(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "broken.yaml")
+	if err := os.WriteFile(cfgPath, []byte("all: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newSettingsTestCmd(t, "--config", cfgPath)
+	if err := resolveSettings(cmd); err == nil {
+		t.Error("expected a broken config to fail an evaluating command")
+	}
+
+	cmd = newSettingsTestCmd(t, "--config", cfgPath)
+	cmd.Annotations = skipConfigLayers
+	if err := resolveSettings(cmd); err != nil {
+		t.Errorf("expected a non-evaluating command to ignore the config, got %v", err)
+	}
+	for _, c := range []*cobra.Command{configLintCmd, configConvertCmd, configSchemaCmd, allowCmd, hookCmd, hookEnvCmd} {
+		if c.Annotations["pathuni/config-layers"] != "skip" {
+			t.Errorf("%s should skip the config layers", c.Name())
+		}
+	}
+}
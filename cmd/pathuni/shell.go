@@ -13,6 +13,26 @@ var supportedShells = map[string]struct{}{
 	"powershell": {},
 }
 
+// configOnlyShells can be configured under `shells:` and evaluated by dump and
+// dry-run, but pathuni does not generate init code for them.
+var configOnlyShells = map[string]struct{}{
+	"nu": {},
+}
+
+func shellIsConfigOnly(s string) bool {
+	_, ok := configOnlyShells[s]
+	return ok
+}
+
+// configOnlyNote explains the rejection of a config-only shell by a command
+// that generates shell code, or returns "" for other shells.
+func configOnlyNote(s string) string {
+	if !shellIsConfigOnly(s) {
+		return ""
+	}
+	return fmt.Sprintf(" ('%s' is config-only: use 'pathuni dump --shell %s --format json' or dry-run)", s, s)
+}
+
 func shellIsValid(s string) bool {
 	_, ok := supportedShells[s]
 	return ok
@@ -27,6 +47,17 @@ func shellNames() []string {
 	return keys
 }
 
+// configShellNames returns the shell names accepted under `shells:`,
+// including the config-only ones.
+func configShellNames() []string {
+	keys := shellNames()
+	for k := range configOnlyShells {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 var renderers = map[string]func([]string) string{
 	"bash":       renderBash,
 	"zsh":        renderBash,
@@ -40,9 +71,17 @@ var renderers = map[string]func([]string) string{
 	"powershell": renderPwsh,
 }
 
+// deferPlan describes the code emitted by --defer-env: patterns stripped from
+// the live PATH, then the pathuni entries placed before and after it.
+type deferPlan struct {
+    Prefix []string
+    Suffix []string
+    Remove []string // exact paths or glob patterns
+}
+
 // Defer renderers: generate code that references the live PATH at evaluation
-// time, prepending the provided pathuni paths (pathuni-first semantics).
-var renderersDefer = map[string]func([]string) string{
+// time, prepending the prefix paths and appending the suffix paths around it.
+var renderersDefer = map[string]func(deferPlan) string{
     "bash":       renderBashDefer,
     "zsh":        renderBashDefer,
     "sh":         renderBashDefer,
@@ -67,28 +106,172 @@ func renderPwsh(paths []string) string {
     return fmt.Sprintf("$env:PATH = \"%s\"", strings.Join(paths, ":"))
 }
 
-func renderBashDefer(paths []string) string {
-    joined := strings.Join(paths, ":")
-    if joined == "" {
-        return "export PATH=\"${PATH}\""
+// placed returns the entries a deferPlan puts around the live PATH. They are
+// stripped from it first so evaluating the code again leaves PATH unchanged.
+func (plan deferPlan) placed() []string {
+    return append(append([]string{}, plan.Prefix...), plan.Suffix...)
+}
+
+// apply returns the PATH the plan's code produces from the live PATH.
+func (plan deferPlan) apply(live []string) []string {
+    placed := make(map[string]bool)
+    for _, p := range plan.placed() {
+        placed[p] = true
+    }
+    out := append([]string{}, plan.Prefix...)
+    for _, e := range live {
+        drop := placed[e]
+        for _, pattern := range plan.Remove {
+            drop = drop || matchRemovePattern(pattern, e)
+        }
+        if !drop {
+            out = append(out, e)
+        }
+    }
+    return append(out, plan.Suffix...)
+}
+
+func renderBashDefer(plan deferPlan) string {
+    var lines []string
+    if len(plan.Remove) > 0 {
+        lines = append(lines, renderBashRemove(plan.Remove))
+    }
+    if placed := plan.placed(); len(placed) > 0 {
+        lines = append(lines, renderBashStrip(placed))
+    }
+    parts := append(append(append([]string{}, plan.Prefix...), "${PATH}"), plan.Suffix...)
+    lines = append(lines, fmt.Sprintf("export PATH=\"%s\"", strings.Join(parts, ":")))
+    return strings.Join(lines, "\n")
+}
+
+func renderFishDefer(plan deferPlan) string {
+    var stmts []string
+    if len(plan.Remove) > 0 {
+        stmts = append(stmts, renderFishRemove(plan.Remove))
+    }
+    if placed := plan.placed(); len(placed) > 0 {
+        stmts = append(stmts, renderFishStrip(placed))
+    }
+    parts := append(append(append([]string{}, plan.Prefix...), "$PATH"), plan.Suffix...)
+    stmts = append(stmts, fmt.Sprintf("set -gx PATH %s", strings.Join(parts, " ")))
+    // fish evaluates `eval (pathuni init)` as a single line
+    return strings.Join(stmts, "; ")
+}
+
+func renderPwshDefer(plan deferPlan) string {
+    var lines []string
+    if len(plan.Remove) > 0 {
+        lines = append(lines, renderPwshRemove(plan.Remove))
+    }
+    if placed := plan.placed(); len(placed) > 0 {
+        lines = append(lines, renderPwshStrip(placed))
+    }
+    // ${env:PATH} keeps a following ":" from being read as part of the name
+    live := "$env:PATH"
+    if len(plan.Suffix) > 0 {
+        live = "${env:PATH}"
+    }
+    parts := append(append(append([]string{}, plan.Prefix...), live), plan.Suffix...)
+    lines = append(lines, fmt.Sprintf("$env:PATH = \"%s\"", strings.Join(parts, ":")))
+    return strings.Join(lines, "\n")
+}
+
+// shQuote single-quotes s for POSIX shells.
+func shQuote(s string) string {
+    return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish.
+func fishQuote(s string) string {
+    return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// pwshQuote single-quotes s for PowerShell.
+func pwshQuote(s string) string {
+    return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// renderBashRemove strips entries matching patterns from the live PATH using
+// only POSIX parameter expansion (zsh does not word-split $PATH by default).
+// "*" in a case pattern also matches "/", so an entry only counts as matched
+// when it has no more "/" than the pattern; patterns are grouped by that count.
+func renderBashRemove(patterns []string) string {
+    var depths []int
+    groups := make(map[int][]string)
+    for _, p := range patterns {
+        tokens, err := parseGlob(p)
+        if err != nil {
+            continue
+        }
+        n := globSlashes(tokens)
+        if _, ok := groups[n]; !ok {
+            depths = append(depths, n)
+        }
+        groups[n] = append(groups[n], globCasePattern(tokens))
+    }
+    tests := make([]string, 0, len(depths))
+    for _, n := range depths {
+        deeper := "*" + strings.Repeat("/*", n+1)
+        tests = append(tests, "case \"$__pathuni_e\" in "+strings.Join(groups[n], "|")+") case \"$__pathuni_e\" in "+deeper+") ;; *) __pathuni_k= ;; esac ;; esac")
+    }
+    return renderBashFilter(strings.Join(tests, "; "))
+}
+
+// renderBashStrip strips exact entries from the live PATH; quoted case
+// patterns match literally.
+func renderBashStrip(entries []string) string {
+    quoted := make([]string, 0, len(entries))
+    for _, e := range entries {
+        quoted = append(quoted, shQuote(e))
+    }
+    return renderBashFilter("case \"$__pathuni_e\" in " + strings.Join(quoted, "|") + ") __pathuni_k= ;; esac")
+}
+
+// renderBashFilter keeps the live PATH entries for which test, run with the
+// entry in $__pathuni_e, leaves $__pathuni_k set. Empty entries (the current
+// directory) are kept like any other: every kept entry is appended after a
+// ':' and the leading one is dropped at the end.
+func renderBashFilter(test string) string {
+    return "__pathuni_r=\"${PATH}:\"; __pathuni_p=; " +
+        "while [ -n \"$__pathuni_r\" ]; do __pathuni_e=\"${__pathuni_r%%:*}\"; __pathuni_r=\"${__pathuni_r#*:}\"; __pathuni_k=1; " +
+        test + "; [ -z \"$__pathuni_k\" ] || __pathuni_p=\"$__pathuni_p:$__pathuni_e\"; done; " +
+        "PATH=\"${__pathuni_p#:}\"; unset __pathuni_r __pathuni_p __pathuni_e __pathuni_k"
+}
+
+// removeRegexp joins patterns into one anchored regexp for fish and
+// PowerShell, matching what matchRemovePattern matches.
+func removeRegexp(patterns []string) string {
+    alts := make([]string, 0, len(patterns))
+    for _, p := range patterns {
+        if tokens, err := parseGlob(p); err == nil {
+            alts = append(alts, globRegexp(tokens))
+        }
     }
-    return fmt.Sprintf("export PATH=\"%s:${PATH}\"", joined)
+    return "^(?:" + strings.Join(alts, "|") + ")$"
+}
+
+func renderFishRemove(patterns []string) string {
+    return "set -gx PATH (string match -rv -- " + fishQuote(removeRegexp(patterns)) + " $PATH)"
 }
 
-func renderFishDefer(paths []string) string {
-    joined := strings.Join(paths, " ")
-    if joined == "" {
-        return "set -gx PATH $PATH"
+func renderFishStrip(entries []string) string {
+    quoted := make([]string, 0, len(entries))
+    for _, e := range entries {
+        quoted = append(quoted, fishQuote(e))
     }
-    return fmt.Sprintf("set -gx PATH %s $PATH", joined)
+    return "set -gx PATH (for __pathuni_e in $PATH; contains -- $__pathuni_e " + strings.Join(quoted, " ") + "; or printf '%s\\n' $__pathuni_e; end); set -e __pathuni_e"
 }
 
-func renderPwshDefer(paths []string) string {
-    joined := strings.Join(paths, ":")
-    if joined == "" {
-        return "$env:PATH = \"$env:PATH\""
+func renderPwshRemove(patterns []string) string {
+    return "$env:PATH = (($env:PATH -split ':') | Where-Object { $_ -cnotmatch " + pwshQuote(removeRegexp(patterns)) + " }) -join ':'"
+}
+
+func renderPwshStrip(entries []string) string {
+    quoted := make([]string, 0, len(entries))
+    for _, e := range entries {
+        quoted = append(quoted, pwshQuote(e))
     }
-    return fmt.Sprintf("$env:PATH = \"%s:$env:PATH\"", joined)
+    return "$env:PATH = (($env:PATH -split ':') | Where-Object { @(" + strings.Join(quoted, ", ") + ") -notcontains $_ }) -join ':'"
 }
 
 func runInit() {
@@ -101,7 +284,7 @@ func runInit() {
     }
 
     if !shellIsValid(shellName) {
-        fmt.Fprintf(os.Stderr, "Unsupported shell '%s'. Supported shells: %s\n", shellName, strings.Join(shellNames(), ", "))
+        fmt.Fprintf(os.Stderr, "Unsupported shell '%s'. Supported shells: %s%s\n", shellName, strings.Join(shellNames(), ", "), configOnlyNote(shellName))
         os.Exit(1)
     }
 
@@ -114,6 +297,10 @@ func runInit() {
         fmt.Fprintf(os.Stderr, "Error: Invalid prune option '%s'. Use 'none', 'pathuni', 'system', or 'all'\n", prune)
         os.Exit(1)
     }
+    if !isValidDedupe(dedupe) {
+        fmt.Fprintf(os.Stderr, "Error: Invalid dedupe option '%s'. Use 'exact', 'clean', or 'realpath'\n", dedupe)
+        os.Exit(1)
+    }
 
     // Handle defer-env for init: prepend pathuni and reference live PATH
     if deferEnv {
@@ -125,53 +312,78 @@ func runInit() {
             fmt.Fprintf(os.Stderr, "Error: --prune=%s is incompatible with --defer-env (system PATH is not expanded)\n", prune)
             os.Exit(1)
         }
-        pu, err := resolvePathuniPaths()
+        statuses, err := evaluatePathuni()
         if err != nil {
             fmt.Fprintf(os.Stderr, "Error: %v\n", err)
             os.Exit(1)
         }
-        fmt.Println(renderersDefer[shellName](pu))
+        prefix, suffix := splitDeferPlaced(placedFromStatuses(statuses, prunePathuni()))
+        plan := deferPlan{Prefix: prefix, Suffix: suffix, Remove: configRemovePatterns(getConfigPath(), osName)}
+        code := renderersDefer[shellName](plan)
+        if withRevert {
+            code = withRevertCode(shellName, code, addedEntries(append(append([]string{}, prefix...), suffix...)))
+        }
+        fmt.Println(code)
+        if autoSnapshot {
+            live, _ := getCurrentPath()
+            autoSnapshotInit(osName, shellName, plan.apply(live))
+        }
+        warnMissingRequired(shellName, missingRequired(statuses))
         return
     }
 
-    // Compute the list based on scope using shared resolvers
-    var paths []string
+    paths, statuses, err := resolveInitPaths(osName, shellName)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+        os.Exit(1)
+    }
+
+    // Generate PATH export using original renderers
+    code := renderers[shellName](paths)
+    if withRevert {
+        code = withRevertCode(shellName, code, addedEntries(paths))
+    }
+    fmt.Println(code)
+    if autoSnapshot {
+        autoSnapshotInit(osName, shellName, paths)
+    }
+    warnMissingRequired(shellName, missingRequired(statuses))
+}
+
+// resolveInitPaths computes the PATH init exports for the current scope and
+// prune settings. It also returns the evaluated pathuni entries (nil under
+// scope=system) for the required-entry checks.
+func resolveInitPaths(osName, shellName string) ([]string, []PathStatus, error) {
+    var statuses []PathStatus
+    if scope != "system" {
+        var err error
+        if statuses, err = evaluatePathuni(); err != nil {
+            return nil, nil, err
+        }
+    }
+    pu := placedFromStatuses(statuses, prunePathuni())
     switch scope {
     case "system":
         p, err := resolveSystemPathsContext(getConfigPath(), osName, shellName)
         if err != nil {
-            fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-            os.Exit(1)
+            return nil, nil, err
         }
         if prune == "system" || prune == "all" {
             p = filterExisting(p)
         }
-        paths = p
+        return p, nil, nil
     case "pathuni":
-        p, err := resolvePathuniPaths()
-        if err != nil {
-            fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-            os.Exit(1)
-        }
-        paths = p
+        return mergePlaced(pu, nil), statuses, nil
     case "full":
         sys, err := resolveSystemPathsContext(getConfigPath(), osName, shellName)
         if err != nil {
-            fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-            os.Exit(1)
+            return nil, nil, err
         }
         if prune == "system" || prune == "all" {
             sys = filterExisting(sys)
         }
-        pu, err := resolvePathuniPaths()
-        if err != nil {
-            fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-            os.Exit(1)
-        }
-        // pathuni-first precedence for init
-        paths = mergeFull(pu, sys, true)
+        // pathuni-first precedence for init, honouring entry positions
+        return mergePlaced(pu, sys), statuses, nil
     }
-
-    // Generate PATH export using original renderers
-    fmt.Println(renderers[shellName](paths))
+    return nil, nil, fmt.Errorf("invalid scope: %s", scope)
 }
@@ -0,0 +1,101 @@
+package main
+
+// Shell-specific configuration. A platform's `shells:` map configures each
+// shell separately: whether it gets the system paths other shells load from
+// /etc/paths (see system_sources.go), how they are classified, and extra
+// paths of its own. The older `powershell:` key is an alias for
+// `shells: {powershell: ...}`.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// shellConfigFor returns the configuration of shell on a platform, or nil.
+func shellConfigFor(pc PlatformConfig, shell string) *ShellConfig {
+	if sc, ok := pc.Shells[shell]; ok && sc != nil {
+		return sc
+	}
+	if shell == "powershell" {
+		return pc.PowerShell
+	}
+	return nil
+}
+
+// systemPathsAs returns the classification of the system paths included for
+// a shell ("system" or "pathuni"), or "" when they are not included.
+func systemPathsAs(sc *ShellConfig) string {
+	if sc == nil || !sc.IncludeSystemPaths {
+		return ""
+	}
+	if sc.IncludeSystemPathsAs == "" {
+		return "system"
+	}
+	return sc.IncludeSystemPathsAs
+}
+
+// getShellPathEntries returns the pathuni-side entries a shell adds on a
+// platform: its own paths, then the system paths when classified as
+// pathuni. Entries without tags get the shell's tags when set, otherwise
+// (Tags=nil) they inherit the platform tags.
+func getShellPathEntries(shell string, pc PlatformConfig, context string) ([]PathEntry, error) {
+	sc := shellConfigFor(pc, shell)
+	if sc == nil {
+		return nil, nil
+	}
+	entries, err := extractPathEntries(sc.Paths, fmt.Sprintf("%s.shells.%s.paths", context, shell))
+	if err != nil {
+		return nil, err
+	}
+	if systemPathsAs(sc) == "pathuni" {
+		if sys, err := getSystemPathsFrom(sc.SystemSources); err == nil {
+			for _, p := range sys {
+				entries = append(entries, PathEntry{Path: p})
+			}
+		}
+	}
+	if sc.Tags != nil {
+		for i := range entries {
+			if entries[i].Tags == nil {
+				// Explicit tags provided (possibly empty slice to break inheritance)
+				entries[i].Tags = append([]string{}, sc.Tags...)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// validateShellConfigs checks the shell names and settings of a platform.
+func validateShellConfigs(pc PlatformConfig, context string) error {
+	if pc.PowerShell != nil && pc.Shells["powershell"] != nil {
+		return fmt.Errorf("both %s.powershell and %s.shells.powershell are set; use one", context, context)
+	}
+	names := make([]string, 0, len(pc.Shells))
+	for name := range pc.Shells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !shellIsValid(name) && !shellIsConfigOnly(name) {
+			return fmt.Errorf("unknown shell '%s' in %s.shells. Supported shells: %s, and nu (config-only: dump and dry-run)", name, context, strings.Join(shellNames(), ", "))
+		}
+		if err := validateShellConfig(pc.Shells[name], fmt.Sprintf("%s.shells.%s", context, name)); err != nil {
+			return err
+		}
+	}
+	return validateShellConfig(pc.PowerShell, context+".powershell")
+}
+
+func validateShellConfig(sc *ShellConfig, context string) error {
+	if sc == nil {
+		return nil
+	}
+	if as := sc.IncludeSystemPathsAs; as != "" && as != "system" && as != "pathuni" {
+		return fmt.Errorf("invalid include_system_paths_as '%s' in %s. Use 'system' or 'pathuni'", as, context)
+	}
+	if err := validateTags(sc.Tags, context+".tags"); err != nil {
+		return err
+	}
+	return validateSystemSources(sc, context)
+}
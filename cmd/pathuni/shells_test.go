@@ -0,0 +1,123 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const shellsTestConfig = `all:
+  shells:
+    zsh:
+      paths:
+        - /tmp/pathuni/home/Pratt/bin
+macos:
+  tags: [mac]
+  paths:
+    - /tmp/pathuni/opt/tools
+  shells:
+    fish:
+      include_system_paths: true
+      include_system_paths_as: pathuni
+      tags: [fishy]
+      paths:
+        - /tmp/pathuni/home/Pratt/.local/bin
+        - path: /tmp/pathuni/opt/dev/bin
+          tags: [dev]
+    bash:
+      include_system_paths: true
+`
+
+func TestShells_Entries(t *testing.T) {
+	setupTestFilesystem(t)
+	withTestRoot(t, filepath.Join("testdata", "system_paths"))
+	t.Setenv("PATH", "/tmp/pathuni/bin")
+
+	cfgPath := filepath.Join(t.TempDir(), "shells.yaml")
+	writeFile(t, cfgPath, shellsTestConfig)
+
+	// fish gets its own paths and the system paths on the pathuni side
+	statuses, _, err := EvaluateConfigDetailed(cfgPath, "macOS", "fish", TagFilter{})
+	if err != nil {
+		t.Fatalf("EvaluateConfigDetailed: %v", err)
+	}
+	tags := map[string]string{}
+	for _, st := range statuses {
+		tags[st.Path] = strings.Join(st.Tags, ",")
+	}
+	for path, want := range map[string]string{
+		"/tmp/pathuni/opt/tools":             "mac",
+		"/tmp/pathuni/home/Pratt/.local/bin": "fishy",
+		"/tmp/pathuni/opt/dev/bin":           "dev",
+		"/tmp/pathuni/opt/homebrew/bin":      "fishy",
+	} {
+		if got, ok := tags[path]; !ok || got != want {
+			t.Errorf("%s: tags %q (present %v), want %q", path, got, ok, want)
+		}
+	}
+	if _, ok := tags["/tmp/pathuni/home/Pratt/bin"]; ok {
+		t.Errorf("zsh paths must not apply to fish")
+	}
+
+	// Tag filters apply to shell paths
+	filter, _ := parseTagFlags("", "fishy")
+	result, err := EvaluateConfigWithReasons(cfgPath, "macOS", "fish", filter)
+	if err != nil {
+		t.Fatalf("EvaluateConfigWithReasons: %v", err)
+	}
+	if got := strings.Join(result.IncludedPaths, ":"); got != "/tmp/pathuni/opt/tools:/tmp/pathuni/opt/dev/bin" {
+		t.Errorf("unexpected included paths %s", got)
+	}
+
+	// bash gets the system paths on the system side
+	sys, err := resolveSystemPathsContext(cfgPath, "macOS", "bash")
+	if err != nil {
+		t.Fatalf("resolveSystemPathsContext: %v", err)
+	}
+	if len(sys) < 2 || sys[0] != "/tmp/pathuni/bin" || sys[1] != "/tmp/pathuni/usr/local/bin" {
+		t.Errorf("expected system paths appended for bash, got %v", sys)
+	}
+	if sys, _ := resolveSystemPathsContext(cfgPath, "macOS", "zsh"); strings.Join(sys, ":") != "/tmp/pathuni/bin" {
+		t.Errorf("zsh does not include system paths, got %v", sys)
+	}
+
+	// all.shells applies on every platform
+	statuses, _, err = EvaluateConfigDetailed(cfgPath, "Linux", "zsh", TagFilter{})
+	if err != nil || len(statuses) != 1 || statuses[0].Path != "/tmp/pathuni/home/Pratt/bin" {
+		t.Errorf("expected the zsh path from all.shells, got %v, %v", statuses, err)
+	}
+}
+
+func TestShells_Validation(t *testing.T) {
+	for _, tt := range []struct {
+		content, want string
+	}{
+		{"macos:\n  shells:\n    tcsh:\n      include_system_paths: true\n", "unknown shell 'tcsh' in macos.shells"},
+		{"linux:\n  powershell:\n    include_system_paths: true\n  shells:\n    powershell:\n      include_system_paths: true\n", "both linux.powershell and linux.shells.powershell are set"},
+		{"all:\n  shells:\n    fish:\n      include_system_paths_as: both\n", "invalid include_system_paths_as 'both' in all.shells.fish"},
+		{"macos:\n  shells:\n    fish:\n      pth: /opt/bin\n", `unknown field "pth" in macos.shells.fish`},
+	} {
+		cfgPath := filepath.Join(t.TempDir(), "shells.yaml")
+		writeFile(t, cfgPath, tt.content)
+		if _, err := loadConfig(cfgPath); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("expected %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestShells_NuIsConfigOnly(t *testing.T) {
+	setupTestFilesystem(t)
+
+	cfgPath := filepath.Join(t.TempDir(), "nu.yaml")
+	writeFile(t, cfgPath, "all:\n  shells:\n    nu:\n      paths:\n        - /tmp/pathuni/opt/tools\n")
+	statuses, _, err := EvaluateConfigDetailed(cfgPath, "Linux", "nu", TagFilter{})
+	if err != nil || len(statuses) != 1 || statuses[0].Path != "/tmp/pathuni/opt/tools" {
+		t.Errorf("expected the nu path to be evaluated, got %v, %v", statuses, err)
+	}
+	if normalizeShellName("nushell") != "nu" {
+		t.Error("nushell should normalise to nu")
+	}
+	if shellIsValid("nu") || !strings.Contains(configOnlyNote("nu"), "config-only") || configOnlyNote("fish") != "" {
+		t.Error("nu should be rejected by init with a config-only note")
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+// PATH snapshots. `pathuni snapshot save` records the live PATH and the PATH
+// pathuni computes, along with a hash of the config, so a bad config change
+// can be rolled back with `snapshot restore`. With --auto-snapshot, init
+// saves one whenever the computed PATH changes, keeping a bounded history.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// autoSnapshot makes init save a snapshot when the computed PATH changes.
+var autoSnapshot bool
+
+// snapshotLive makes diff and restore use the live PATH of the snapshots.
+var snapshotLive bool
+
+// autoSnapshotLimit is the number of automatic snapshots kept. Snapshots
+// saved by hand are never removed.
+const autoSnapshotLimit = 20
+
+// autoSnapshotPrefix starts the names of automatic snapshots.
+const autoSnapshotPrefix = "auto-"
+
+var snapshotNameRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// snapshotTime is the clock used for snapshots; tests replace it.
+var snapshotTime = time.Now
+
+// snapshot is one recorded PATH state, stored as JSON.
+type snapshot struct {
+	Name       string    `json:"name"`
+	Created    time.Time `json:"created"`
+	OS         string    `json:"os"`
+	Shell      string    `json:"shell"`
+	Config     string    `json:"config"`
+	ConfigHash string    `json:"config_hash"`
+	Live       []string  `json:"live"`
+	Computed   []string  `json:"computed"`
+}
+
+// snapshotDir returns the directory holding snapshots.
+func snapshotDir() string {
+	return filepath.Join(xdgBaseDir("XDG_STATE_HOME", ".local", "state"), "pathuni", "snapshots")
+}
+
+func snapshotFile(name string) string {
+	return filepath.Join(snapshotDir(), name+".json")
+}
+
+// configHash returns a hash over the contents of the config files, so
+// snapshots record which config produced them. Missing files are skipped.
+func configHash(configPath string) string {
+	h := sha256.New()
+	for _, path := range filepath.SplitList(configPath) {
+		if sum, err := fileHash(path); err == nil {
+			fmt.Fprintf(h, "%s\n", sum)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newSnapshot records the live PATH and the computed one.
+func newSnapshot(name, osName, shellName string, computed []string) snapshot {
+	live, _ := getCurrentPath()
+	configPath := getConfigPath()
+	return snapshot{
+		Name:       name,
+		Created:    snapshotTime().UTC(),
+		OS:         osName,
+		Shell:      shellName,
+		Config:     configPath,
+		ConfigHash: configHash(configPath),
+		Live:       live,
+		Computed:   computed,
+	}
+}
+
+func writeSnapshot(s snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(snapshotFile(s.Name), append(data, '\n'))
+}
+
+func readSnapshot(name string) (snapshot, error) {
+	var s snapshot
+	if !snapshotNameRegex.MatchString(name) {
+		return s, fmt.Errorf("invalid snapshot name '%s'", name)
+	}
+	data, err := os.ReadFile(snapshotFile(name))
+	if os.IsNotExist(err) {
+		return s, fmt.Errorf("no snapshot named '%s'. Run 'pathuni snapshot list' to see saved snapshots", name)
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("snapshot '%s': %v", name, err)
+	}
+	return s, nil
+}
+
+// listSnapshots returns the saved snapshots, oldest first. Unreadable files
+// are skipped.
+func listSnapshots() []snapshot {
+	entries, err := os.ReadDir(snapshotDir())
+	if err != nil {
+		return nil
+	}
+	var snaps []snapshot
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if s, err := readSnapshot(name); err == nil {
+			snaps = append(snaps, s)
+		}
+	}
+	sort.SliceStable(snaps, func(i, j int) bool {
+		if snaps[i].Created.Equal(snaps[j].Created) {
+			return snaps[i].Name < snaps[j].Name
+		}
+		return snaps[i].Created.Before(snaps[j].Created)
+	})
+	return snaps
+}
+
+// defaultSnapshotName names a snapshot after the current time, adding a
+// counter when the name is taken.
+func defaultSnapshotName(prefix string) string {
+	base := prefix + snapshotTime().UTC().Format("20060102-150405")
+	name := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(snapshotFile(name)); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// autoSnapshotInit saves a snapshot of computed when it differs from the
+// latest automatic snapshot for the same OS and shell, then trims the
+// automatic ones to autoSnapshotLimit. Failures are ignored: init must keep
+// working without a state directory.
+func autoSnapshotInit(osName, shellName string, computed []string) {
+	var latest *snapshot
+	for _, s := range listSnapshots() {
+		if strings.HasPrefix(s.Name, autoSnapshotPrefix) && s.OS == osName && s.Shell == shellName {
+			latest = &s
+		}
+	}
+	if latest != nil && strings.Join(latest.Computed, ":") == strings.Join(computed, ":") {
+		return
+	}
+	if writeSnapshot(newSnapshot(defaultSnapshotName(autoSnapshotPrefix), osName, shellName, computed)) != nil {
+		return
+	}
+
+	var auto []snapshot
+	for _, s := range listSnapshots() {
+		if strings.HasPrefix(s.Name, autoSnapshotPrefix) {
+			auto = append(auto, s)
+		}
+	}
+	for len(auto) > autoSnapshotLimit {
+		os.Remove(snapshotFile(auto[0].Name))
+		auto = auto[1:]
+	}
+}
+
+// snapshotPath returns the PATH of a snapshot used by diff and restore.
+func snapshotPath(s snapshot) []string {
+	if snapshotLive {
+		return s.Live
+	}
+	return s.Computed
+}
+
+// diffPaths returns a line diff of two PATHs: entries prefixed with "  "
+// are in both, "- " only in a, and "+ " only in b.
+func diffPaths(a, b []string) []string {
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	return lines
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save, compare and restore PATH snapshots",
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save [name]",
+	Short: "Save the live and computed PATH (default name: current time)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSnapshotSave(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List saved snapshots",
+	Args:        cobra.NoArgs,
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		runSnapshotList()
+	},
+}
+
+var snapshotShowCmd = &cobra.Command{
+	Use:         "show <name>",
+	Short:       "Show a snapshot",
+	Args:        cobra.ExactArgs(1),
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSnapshotShow(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:         "diff <a> <b>",
+	Short:       "Compare the PATH of two snapshots",
+	Args:        cobra.ExactArgs(2),
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSnapshotDiff(args[0], args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:         "restore <name>",
+	Short:       "Print shell code restoring a snapshot's PATH",
+	Args:        cobra.ExactArgs(1),
+	Annotations: skipConfigLayers,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSnapshotRestore(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func runSnapshotSave(args []string) error {
+	osName, _ := getOSName()
+	shellName, _ := getShellName()
+	if !osIsValid(osName) {
+		return fmt.Errorf("unsupported OS '%s'. Supported OS: %s", osName, strings.Join(osNames(), ", "))
+	}
+	if !shellIsValid(shellName) {
+		return fmt.Errorf("unsupported shell '%s'. Supported shells: %s%s", shellName, strings.Join(shellNames(), ", "), configOnlyNote(shellName))
+	}
+
+	name := defaultSnapshotName("")
+	if len(args) == 1 {
+		name = args[0]
+		if !snapshotNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid snapshot name '%s': use letters, digits, '.', '_' and '-'", name)
+		}
+		// Automatic snapshots are trimmed by name
+		if strings.HasPrefix(name, autoSnapshotPrefix) {
+			return fmt.Errorf("invalid snapshot name '%s': names starting with '%s' are reserved for automatic snapshots", name, autoSnapshotPrefix)
+		}
+	}
+	computed, statuses, err := resolveInitPaths(osName, shellName)
+	if err != nil {
+		return err
+	}
+	reportMissingRequired(missingRequired(statuses))
+	s := newSnapshot(name, osName, shellName, computed)
+	if err := writeSnapshot(s); err != nil {
+		return err
+	}
+	fmt.Printf("Saved snapshot %s (%d computed, %d live entries)\n", s.Name, len(s.Computed), len(s.Live))
+	return nil
+}
+
+func runSnapshotList() {
+	snaps := listSnapshots()
+	if len(snaps) == 0 {
+		fmt.Println("No snapshots saved")
+		return
+	}
+	width := 0
+	for _, s := range snaps {
+		width = max(width, len(s.Name))
+	}
+	for _, s := range snaps {
+		fmt.Printf("%-*s  %s  %s/%s  %d entries  config %s\n", width, s.Name, s.Created.Local().Format("2006-01-02 15:04:05"), s.OS, s.Shell, len(s.Computed), shortHash(s.ConfigHash))
+	}
+}
+
+func runSnapshotShow(name string) error {
+	s, err := readSnapshot(name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot: %s\n", s.Name)
+	fmt.Printf("Created : %s\n", s.Created.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("OS      : %s\n", s.OS)
+	fmt.Printf("Shell   : %s\n", s.Shell)
+	fmt.Printf("Config  : %s (sha256 %s)\n", s.Config, shortHash(s.ConfigHash))
+	for _, section := range []struct {
+		title string
+		paths []string
+	}{{"Computed PATH", s.Computed}, {"Live PATH", s.Live}} {
+		fmt.Printf("\n%s (%d):\n", section.title, len(section.paths))
+		for _, p := range section.paths {
+			fmt.Printf("  %s\n", p)
+		}
+	}
+	return nil
+}
+
+func runSnapshotDiff(nameA, nameB string) error {
+	a, err := readSnapshot(nameA)
+	if err != nil {
+		return err
+	}
+	b, err := readSnapshot(nameB)
+	if err != nil {
+		return err
+	}
+	which := "computed"
+	if snapshotLive {
+		which = "live"
+	}
+	fmt.Printf("--- %s (%s PATH, config %s)\n", a.Name, which, shortHash(a.ConfigHash))
+	fmt.Printf("+++ %s (%s PATH, config %s)\n", b.Name, which, shortHash(b.ConfigHash))
+	for _, line := range diffPaths(snapshotPath(a), snapshotPath(b)) {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func runSnapshotRestore(name string) error {
+	shellName, _ := getShellName()
+	if !shellIsValid(shellName) {
+		return fmt.Errorf("unsupported shell '%s'. Supported shells: %s%s", shellName, strings.Join(shellNames(), ", "), configOnlyNote(shellName))
+	}
+	s, err := readSnapshot(name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(renderers[shellName](snapshotPath(s)))
+	return nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
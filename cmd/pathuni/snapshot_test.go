@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withSnapshotClock points the snapshot directory at a temporary state dir
+// and makes every snapshot one second newer than the previous one.
+func withSnapshotClock(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	old := snapshotTime
+	snapshotTime = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	t.Cleanup(func() { snapshotTime = old })
+}
+
+func TestSnapshot_DiffPaths(t *testing.T) {
+	a := []string{"/a", "/b", "/c", "/d"}
+	b := []string{"/b", "/x", "/c", "/a"}
+	want := []string{"- /a", "  /b", "+ /x", "  /c", "- /d", "+ /a"}
+	if got := diffPaths(a, b); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSnapshot_SaveShowRestore(t *testing.T) {
+	withSnapshotClock(t)
+	setupTestFilesystem(t)
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/bin")
+
+	config = filepath.Join(t.TempDir(), "snap.yaml")
+	writeFile(t, config, "all:\n  paths:\n    - /tmp/pathuni/usr/local/bin\n")
+	osOverride, shell, scope, prune = "Linux", "bash", "full", "pathuni"
+	t.Cleanup(func() { config, osOverride, shell, snapshotLive = "", "", "", false })
+
+	out := captureOutput(func() {
+		if err := runSnapshotSave([]string{"good"}); err != nil {
+			t.Errorf("save: %v", err)
+		}
+	})
+	if out != "Saved snapshot good (3 computed, 2 live entries)\n" {
+		t.Errorf("unexpected save output %q", out)
+	}
+	if err := runSnapshotSave([]string{"../escape"}); err == nil {
+		t.Errorf("expected invalid name error")
+	}
+	// Hand-saved snapshots must not be trimmed as automatic ones
+	if err := runSnapshotSave([]string{"auto-mine"}); err == nil || !strings.Contains(err.Error(), "reserved for automatic snapshots") {
+		t.Errorf("expected reserved name error, got %v", err)
+	}
+
+	s, err := readSnapshot("good")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if s.ConfigHash != configHash(config) || s.OS != "Linux" || s.Shell != "bash" {
+		t.Errorf("unexpected snapshot metadata %+v", s)
+	}
+
+	shell = "fish"
+	out = captureOutput(func() { _ = runSnapshotRestore("good") })
+	if out != "set -gx PATH /tmp/pathuni/usr/local/bin /tmp/pathuni/usr/bin /tmp/pathuni/bin\n" {
+		t.Errorf("unexpected restore output %q", out)
+	}
+	snapshotLive = true
+	out = captureOutput(func() { _ = runSnapshotRestore("good") })
+	if out != "set -gx PATH /tmp/pathuni/usr/bin /tmp/pathuni/bin\n" {
+		t.Errorf("unexpected live restore output %q", out)
+	}
+
+	if err := runSnapshotShow("missing"); err == nil || !strings.Contains(err.Error(), "no snapshot named 'missing'") {
+		t.Errorf("expected missing snapshot error, got %v", err)
+	}
+}
+
+func TestSnapshot_AutoSnapshot(t *testing.T) {
+	withSnapshotClock(t)
+
+	// Unchanged PATHs are not saved again
+	autoSnapshotInit("Linux", "bash", []string{"/a"})
+	autoSnapshotInit("Linux", "bash", []string{"/a"})
+	if snaps := listSnapshots(); len(snaps) != 1 || snaps[0].Name != "auto-20250301-120001" {
+		t.Fatalf("expected one automatic snapshot, got %v", snaps)
+	}
+
+	// History is bounded; snapshots saved by hand are kept
+	if err := writeSnapshot(newSnapshot("manual", "Linux", "bash", []string{"/manual"})); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for i := 0; i < autoSnapshotLimit+5; i++ {
+		autoSnapshotInit("Linux", "bash", []string{fmt.Sprintf("/p%d", i)})
+	}
+	snaps := listSnapshots()
+	auto := 0
+	manual := false
+	for _, s := range snaps {
+		if strings.HasPrefix(s.Name, autoSnapshotPrefix) {
+			auto++
+		}
+		manual = manual || s.Name == "manual"
+	}
+	if auto != autoSnapshotLimit || !manual {
+		t.Errorf("expected %d automatic snapshots and the manual one, got %d, manual=%v", autoSnapshotLimit, auto, manual)
+	}
+	if last := snaps[len(snaps)-1]; strings.Join(last.Computed, ":") != fmt.Sprintf("/p%d", autoSnapshotLimit+4) {
+		t.Errorf("latest snapshot should be the last PATH, got %v", last.Computed)
+	}
+}
+
+func TestSnapshot_AutoSnapshotPerShellAndDefer(t *testing.T) {
+	withSnapshotClock(t)
+	countAuto := func() int {
+		n := 0
+		for _, s := range listSnapshots() {
+			if strings.HasPrefix(s.Name, autoSnapshotPrefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Each OS and shell is compared against its own latest automatic snapshot
+	autoSnapshotInit("Linux", "bash", []string{"/a"})
+	autoSnapshotInit("Linux", "zsh", []string{"/a"})
+	autoSnapshotInit("Linux", "bash", []string{"/a"})
+	autoSnapshotInit("macOS", "bash", []string{"/a"})
+	if n := countAuto(); n != 3 {
+		t.Fatalf("expected 3 automatic snapshots, got %d", n)
+	}
+
+	// A snapshot saved by hand does not stand in for an automatic one
+	if err := writeSnapshot(newSnapshot("manual", "Linux", "fish", []string{"/b"})); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	autoSnapshotInit("Linux", "fish", []string{"/b"})
+	if n := countAuto(); n != 4 {
+		t.Fatalf("expected the manual snapshot not to suppress an automatic one, got %d", n)
+	}
+
+	// init -d saves the PATH its code produces from the live PATH
+	setupTestFilesystem(t)
+	config = writeRemoveConfig(t)
+	osOverride = "Linux"
+	shell = "bash"
+	scope = "full"
+	prune = "pathuni"
+	tagsInclude, tagsExclude = "", ""
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin:/tmp/pathuni/usr/games:/tmp/pathuni/snap/bin:/tmp/pathuni/bin")
+	deferEnv, autoSnapshot = true, true
+	defer func() { deferEnv, autoSnapshot = false, false }()
+	captureOutput(runInit)
+	captureOutput(runInit)
+	if n := countAuto(); n != 5 {
+		t.Fatalf("expected one automatic snapshot from init -d, got %d", n-4)
+	}
+	snaps := listSnapshots()
+	want := "/tmp/pathuni/usr/local/bin:/tmp/pathuni/usr/bin:/tmp/pathuni/bin"
+	if got := strings.Join(snaps[len(snaps)-1].Computed, ":"); got != want {
+		t.Errorf("init -d snapshot = %q, want %q", got, want)
+	}
+}
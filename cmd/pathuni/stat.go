@@ -0,0 +1,153 @@
+package main
+
+// Concurrent existence checks. A stale network mount (NFS, SMB, sshfs) makes
+// stat hang, so entries are checked by a bounded pool of workers and, with
+// --stat-timeout, given up on after a deadline. Timed-out entries are kept or
+// skipped according to --stat-timeout-policy and shown as [~] in dry-run.
+// Results keep the order of the input.
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// statTimeout is the per-entry deadline; zero waits indefinitely.
+var statTimeout time.Duration
+
+// statTimeoutPolicy decides whether timed-out entries are kept: include|skip.
+var statTimeoutPolicy string
+
+// statWorkers bounds the number of concurrent checks.
+const statWorkers = 8
+
+// timedOutPaths remembers entries that timed out, so later checks in the
+// same run fail fast and dry-run can mark them.
+var (
+	timedOutMu    sync.Mutex
+	timedOutPaths = map[string]bool{}
+)
+
+func isValidStatTimeoutPolicy(policy string) bool {
+	return policy == "include" || policy == "skip"
+}
+
+// validateStatTimeout checks the --stat-timeout flags.
+func validateStatTimeout() error {
+	if statTimeout < 0 {
+		return fmt.Errorf("--stat-timeout must not be negative, got %s", statTimeout)
+	}
+	if !isValidStatTimeoutPolicy(statTimeoutPolicy) {
+		return fmt.Errorf("invalid stat timeout policy '%s'. Use 'include' or 'skip'", statTimeoutPolicy)
+	}
+	return nil
+}
+
+// realPaths caches the entries resolved under --dedupe realpath, keyed by
+// the path as given; "" marks an entry that could not be resolved.
+var (
+	realPathsMu sync.Mutex
+	realPaths   = map[string]string{}
+)
+
+// statResult is the outcome of checking one entry. Real is set under
+// --dedupe realpath when the entry resolves.
+type statResult struct {
+	Info     fs.FileInfo
+	Err      error
+	Real     string
+	TimedOut bool
+}
+
+// isDir reports whether the entry counts as an existing directory; timed-out
+// entries follow the policy.
+func (r statResult) isDir() bool {
+	if r.TimedOut {
+		return statTimeoutPolicy == "include"
+	}
+	return r.Err == nil && r.Info.IsDir()
+}
+
+// statPaths checks paths concurrently, returning results in input order.
+func statPaths(paths []string) []statResult {
+	results := make([]statResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(statWorkers, len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = statWithTimeout(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// statWithTimeout stats path, giving up after statTimeout. The abandoned
+// stat keeps running in the background; the process does not wait for it.
+// The filesystem and dedupe mode are read up front so the abandoned check
+// does not read globals that may have changed since.
+func statWithTimeout(path string) statResult {
+	fsys, mode := targetFS(), dedupe
+	if statTimeout <= 0 {
+		return checkPath(fsys, mode, path)
+	}
+	if pathTimedOut(path) {
+		return statResult{TimedOut: true}
+	}
+	done := make(chan statResult, 1)
+	go func() {
+		done <- checkPath(fsys, mode, path)
+	}()
+	timer := time.NewTimer(statTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r
+	case <-timer.C:
+		timedOutMu.Lock()
+		timedOutPaths[path] = true
+		timedOutMu.Unlock()
+		return statResult{TimedOut: true}
+	}
+}
+
+// checkPath stats path on fsys and, under --dedupe realpath (mode), resolves
+// it as well so a hanging mount is given up on once for both.
+func checkPath(fsys fs.FS, mode, path string) statResult {
+	info, err := fs.Stat(fsys, fsName(path))
+	r := statResult{Info: info, Err: err}
+	if mode != "realpath" {
+		return r
+	}
+	if err == nil {
+		r.Real, _ = realPathIn(fsys, path)
+	}
+	realPathsMu.Lock()
+	realPaths[path] = r.Real
+	realPathsMu.Unlock()
+	return r
+}
+
+// cachedRealPath returns the resolved path recorded by checkPath.
+func cachedRealPath(path string) (string, bool) {
+	realPathsMu.Lock()
+	defer realPathsMu.Unlock()
+	real, ok := realPaths[path]
+	return real, ok
+}
+
+// pathTimedOut reports whether checking path timed out earlier in this run.
+func pathTimedOut(path string) bool {
+	timedOutMu.Lock()
+	defer timedOutMu.Unlock()
+	return timedOutPaths[path]
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// hangingFS is a MapFS where every name under hang/ blocks like a stale
+// network mount until the test ends.
+type hangingFS struct {
+	fstest.MapFS
+	release chan struct{}
+}
+
+func (h hangingFS) Open(name string) (fs.File, error) {
+	h.wait(name)
+	return h.MapFS.Open(name)
+}
+
+func (h hangingFS) Stat(name string) (fs.FileInfo, error) {
+	h.wait(name)
+	return h.MapFS.Stat(name)
+}
+
+func (h hangingFS) wait(name string) {
+	if strings.HasPrefix(name, "hang/") {
+		<-h.release
+	}
+}
+
+func withHangingFS(t *testing.T, timeout time.Duration, policy string) {
+	t.Helper()
+	fsys := hangingFS{MapFS: fstest.MapFS{}, release: make(chan struct{})}
+	for _, dir := range []string{"hang/nfs/bin", "opt/a/bin", "opt/b/bin", "usr/bin"} {
+		fsys.MapFS[dir] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+	}
+	withRootFS(t, fsys)
+	oldTimeout, oldPolicy := statTimeout, statTimeoutPolicy
+	statTimeout, statTimeoutPolicy = timeout, policy
+	// Cleanups run last-in first-out, so the hung calls are released before
+	// withRootFS and earlier helpers restore their globals. The released
+	// checks only use the filesystem and mode they were started with.
+	t.Cleanup(func() {
+		close(fsys.release)
+		statTimeout, statTimeoutPolicy = oldTimeout, oldPolicy
+		timedOutMu.Lock()
+		timedOutPaths = map[string]bool{}
+		timedOutMu.Unlock()
+	})
+}
+
+func TestStat_ConcurrentOrder(t *testing.T) {
+	withHangingFS(t, 50*time.Millisecond, "skip")
+
+	var paths []string
+	for i := 0; i < 3*statWorkers; i++ {
+		paths = append(paths, fmt.Sprintf("/hang/%d", i))
+	}
+	paths = append(paths, "/opt/a/bin", "/missing", "/opt/b/bin")
+
+	// Hanging entries are checked in parallel, not one deadline after another
+	start := time.Now()
+	results := statPaths(paths)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("checks took %v; expected the workers to overlap", elapsed)
+	}
+	for i, r := range results[:3*statWorkers] {
+		if !r.TimedOut || r.isDir() {
+			t.Errorf("%s: expected a skipped timeout, got %+v", paths[i], r)
+		}
+	}
+	tail := results[3*statWorkers:]
+	if !tail[0].isDir() || tail[1].isDir() || tail[1].TimedOut || !tail[2].isDir() {
+		t.Errorf("results out of order: %+v", tail)
+	}
+
+	// A path that timed out is not waited on again
+	start = time.Now()
+	if r := statWithTimeout("/hang/0"); !r.TimedOut || time.Since(start) > 40*time.Millisecond {
+		t.Errorf("expected a remembered timeout, got %+v after %v", r, time.Since(start))
+	}
+}
+
+func TestStat_TimeoutPolicy(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "stat.yaml")
+	writeFile(t, cfgPath, "linux:\n  paths:\n    - /opt/a/bin\n    - /hang/nfs/bin\n    - /opt/b/bin\n")
+	osOverride, prune, scope = "Linux", "pathuni", "pathuni"
+	tagsInclude, tagsExclude = "", ""
+	t.Cleanup(func() { osOverride, scope = "", "full" })
+
+	for _, tt := range []struct {
+		policy, included, marker string
+	}{
+		{"skip", "/opt/a/bin:/opt/b/bin", "  [~] /hang/nfs/bin (timed out)\n"},
+		{"include", "/opt/a/bin:/hang/nfs/bin:/opt/b/bin", "  [~] /hang/nfs/bin (timed out, included)\n"},
+	} {
+		t.Run(tt.policy, func(t *testing.T) {
+			withHangingFS(t, 50*time.Millisecond, tt.policy)
+			config = cfgPath
+			t.Cleanup(func() { config = "" })
+
+			statuses, _, err := EvaluateConfigDetailed(cfgPath, "Linux", "bash", TagFilter{})
+			if err != nil {
+				t.Fatalf("EvaluateConfigDetailed: %v", err)
+			}
+			var included []string
+			for _, st := range statuses {
+				if st.Included {
+					included = append(included, st.Path)
+				}
+			}
+			if got := strings.Join(included, ":"); got != tt.included {
+				t.Errorf("included %s, want %s", got, tt.included)
+			}
+
+			dry := captureDryRunOutput(func() { _ = PrintDryRunReport(cfgPath, "Linux", "bash", false, false, "pathuni") })
+			if !strings.Contains(dry, tt.marker) {
+				t.Errorf("dry-run missing %q:\n%s", tt.marker, dry)
+			}
+		})
+	}
+}
+
+func TestStat_Validation(t *testing.T) {
+	oldTimeout, oldPolicy := statTimeout, statTimeoutPolicy
+	t.Cleanup(func() { statTimeout, statTimeoutPolicy = oldTimeout, oldPolicy })
+
+	statTimeout, statTimeoutPolicy = 200*time.Millisecond, "wait"
+	if err := validateStatTimeout(); err == nil || !strings.Contains(err.Error(), "invalid stat timeout policy 'wait'") {
+		t.Errorf("expected invalid policy error, got %v", err)
+	}
+	statTimeout, statTimeoutPolicy = -time.Second, "skip"
+	if err := validateStatTimeout(); err == nil {
+		t.Errorf("expected negative timeout error")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+// System PATH sources. include_system_paths reads the directories a login
+// shell would get from the system configuration. macOS keeps them in
+// /etc/paths and /etc/paths.d; Linux spreads them over /etc/environment,
+// /etc/login.defs and /etc/profile.d. Files are parsed, never executed, and
+// read from the target filesystem (see rootfs.go).
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// systemSources maps source names to their readers. Readers return the
+// directories in order and nil when the source is absent.
+var systemSources = map[string]func() []string{
+	"paths":       readEtcPaths,
+	"environment": readEtcEnvironment,
+	"login_defs":  readLoginDefs,
+	"profile_d":   readProfileD,
+	"systemd":     systemdDefaultPaths,
+}
+
+// systemSourceOrder lists the sources in documentation order.
+var systemSourceOrder = []string{"paths", "environment", "login_defs", "profile_d", "systemd"}
+
+// defaultSystemSources are read when system_sources is not set: every
+// file-based source, so the same config works on macOS and Linux.
+var defaultSystemSources = []string{"paths", "environment", "login_defs", "profile_d"}
+
+// getSystemPathsFrom returns the directories of the named sources in order,
+// or of the default sources when none are named.
+func getSystemPathsFrom(sources []string) ([]string, error) {
+	if len(sources) == 0 {
+		sources = defaultSystemSources
+	}
+	var paths []string
+	for _, name := range sources {
+		read, ok := systemSources[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown system source '%s'", name)
+		}
+		paths = append(paths, read()...)
+	}
+	return paths, nil
+}
+
+func validateSystemSources(sc *ShellConfig, context string) error {
+	if sc == nil {
+		return nil
+	}
+	for _, name := range sc.SystemSources {
+		if _, ok := systemSources[name]; !ok {
+			return fmt.Errorf("unknown system source '%s' in %s.system_sources. Supported sources: %s", name, context, strings.Join(systemSourceOrder, ", "))
+		}
+	}
+	return nil
+}
+
+// readEtcPaths reads /etc/paths and the files in /etc/paths.d (macOS).
+func readEtcPaths() []string {
+	paths, _ := readPathsFile("/etc/paths")
+	pathsDir := "/etc/paths.d"
+	if entries, err := readDirPath(pathsDir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				if extra, err := readPathsFile(filepath.Join(pathsDir, entry.Name())); err == nil {
+					paths = append(paths, extra...)
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// readEtcEnvironment reads the PATH assignment of /etc/environment, the
+// file pam_env loads for every session. The last assignment wins.
+func readEtcEnvironment() []string {
+	var paths []string
+	scanLines("/etc/environment", func(line string) {
+		if value, ok := pathAssignment(line); ok {
+			paths = splitStaticPath(value)
+		}
+	})
+	return paths
+}
+
+// readLoginDefs reads ENV_PATH from /etc/login.defs, or ENV_SUPATH when
+// running as root, the PATH login(1) sets. Both "ENV_PATH PATH=/bin" and
+// "ENV_PATH /bin" are accepted.
+func readLoginDefs() []string {
+	key := "ENV_PATH"
+	if os.Geteuid() == 0 {
+		key = "ENV_SUPATH"
+	}
+	var paths []string
+	scanLines("/etc/login.defs", func(line string) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != key {
+			return
+		}
+		paths = splitStaticPath(strings.TrimPrefix(fields[1], "PATH="))
+	})
+	return paths
+}
+
+// readProfileD reads the static PATH assignments of /etc/profile.d/*.sh in
+// the order the shell sources them. References to $PATH are dropped, so
+// "PATH=/opt/x/bin:$PATH" contributes /opt/x/bin.
+func readProfileD() []string {
+	dir := "/etc/profile.d"
+	entries, err := readDirPath(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sh") {
+			continue
+		}
+		scanLines(filepath.Join(dir, entry.Name()), func(line string) {
+			if value, ok := pathAssignment(line); ok {
+				paths = append(paths, splitStaticPath(value)...)
+			}
+		})
+	}
+	return paths
+}
+
+// systemdDefaultPaths returns the list printed by
+// `systemd-path search-binaries-default`. /sbin and /bin are only listed
+// when they are not symlinks into /usr (split-/usr systems).
+func systemdDefaultPaths() []string {
+	paths := []string{"/usr/local/sbin", "/usr/local/bin", "/usr/sbin", "/usr/bin"}
+	for _, dir := range []string{"/sbin", "/bin"} {
+		if info, err := lstatPath(dir); err == nil && info.Mode()&fs.ModeSymlink == 0 {
+			paths = append(paths, dir)
+		}
+	}
+	return paths
+}
+
+// scanLines calls fn with each non-empty, non-comment line of a file.
+func scanLines(path string, fn func(line string)) {
+	file, err := openPath(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			fn(line)
+		}
+	}
+}
+
+// pathAssignment returns the value of a "PATH=..." or "export PATH=..."
+// line, with quotes removed. Unquoted values end at whitespace or ";".
+func pathAssignment(line string) (string, bool) {
+	line = strings.TrimPrefix(line, "export ")
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "PATH=")
+	if !ok {
+		return "", false
+	}
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			return rest[1 : end+1], true
+		}
+		return "", false
+	}
+	if end := strings.IndexAny(rest, " \t;"); end >= 0 {
+		rest = rest[:end]
+	}
+	return rest, true
+}
+
+// splitStaticPath splits a PATH value, dropping empty entries, references
+// to $PATH and entries that need other variables expanded.
+func splitStaticPath(value string) []string {
+	var paths []string
+	for _, p := range strings.Split(value, ":") {
+		if p == "" || strings.Contains(p, "$") || strings.Contains(p, "`") {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
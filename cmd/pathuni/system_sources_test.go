@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSystemSources_Linux(t *testing.T) {
+	withTestRoot(t, filepath.Join("testdata", "linux_system"))
+
+	loginDefs := []string{"/tmp/pathuni/usr/local/bin", "/tmp/pathuni/usr/bin", "/tmp/pathuni/bin"}
+	if os.Geteuid() == 0 {
+		loginDefs = []string{"/tmp/pathuni/usr/local/sbin", "/tmp/pathuni/usr/sbin", "/tmp/pathuni/sbin"}
+	}
+	tests := []struct {
+		sources []string
+		want    []string
+	}{
+		{[]string{"environment"}, []string{"/tmp/pathuni/usr/local/sbin", "/tmp/pathuni/usr/local/bin", "/tmp/pathuni/usr/bin", "/tmp/pathuni/bin"}},
+		{[]string{"login_defs"}, loginDefs},
+		{[]string{"profile_d"}, []string{"/tmp/pathuni/usr/local/go/bin", "/tmp/pathuni/usr/local/node/bin"}},
+		{[]string{"paths"}, nil},
+		{[]string{"profile_d", "environment"}, []string{"/tmp/pathuni/usr/local/go/bin", "/tmp/pathuni/usr/local/node/bin", "/tmp/pathuni/usr/local/sbin", "/tmp/pathuni/usr/local/bin", "/tmp/pathuni/usr/bin", "/tmp/pathuni/bin"}},
+	}
+	for _, tt := range tests {
+		got, err := getSystemPathsFrom(tt.sources)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.sources, err)
+		}
+		if strings.Join(got, ":") != strings.Join(tt.want, ":") {
+			t.Errorf("%v: got %v, want %v", tt.sources, got, tt.want)
+		}
+	}
+}
+
+func TestSystemSources_Systemd(t *testing.T) {
+	withRootFS(t, fstest.MapFS{
+		"sbin":    {Mode: fs.ModeDir | 0755},
+		"usr/bin": {Mode: fs.ModeDir | 0755},
+		"bin":     {Mode: fs.ModeSymlink | 0777, Data: []byte("usr/bin")},
+	})
+	got := systemdDefaultPaths()
+	want := "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin"
+	if strings.Join(got, ":") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestSystemSources_PathAssignment(t *testing.T) {
+	tests := []struct {
+		line, want string
+		ok         bool
+	}{
+		{`PATH=/a:/b`, "/a:/b", true},
+		{`export PATH="/a b:$PATH"`, "/a b:$PATH", true},
+		{`PATH='/a'; export PATH`, "/a", true},
+		{`PATH=/a; export PATH`, "/a", true},
+		{`MANPATH=/a`, "", false},
+		{`PATH="/a`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := pathAssignment(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("pathAssignment(%q) = %q, %v; want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSystemSources_Config(t *testing.T) {
+	setupTestFilesystem(t)
+	withTestRoot(t, filepath.Join("testdata", "linux_system"))
+	t.Setenv("PATH", "/tmp/pathuni/usr/bin")
+
+	cfgPath := filepath.Join(t.TempDir(), "sources.yaml")
+	writeFile(t, cfgPath, `linux:
+  powershell:
+    include_system_paths: true
+    system_sources: [profile_d]
+`)
+	sys, err := resolveSystemPathsContext(cfgPath, "Linux", "powershell")
+	if err != nil {
+		t.Fatalf("resolveSystemPathsContext: %v", err)
+	}
+	want := "/tmp/pathuni/usr/bin:/tmp/pathuni/usr/local/go/bin:/tmp/pathuni/usr/local/node/bin"
+	if strings.Join(sys, ":") != want {
+		t.Errorf("got %v, want %s", sys, want)
+	}
+
+	writeFile(t, cfgPath, "linux:\n  powershell:\n    system_sources: [launchd]\n")
+	if _, err := loadConfig(cfgPath); err == nil || !strings.Contains(err.Error(), "unknown system source 'launchd' in linux.powershell.system_sources") {
+		t.Errorf("expected unknown system source error, got %v", err)
+	}
+}
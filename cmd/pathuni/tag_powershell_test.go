@@ -23,13 +23,11 @@ func captureOut(f func()) string {
 // Stage 2: explicit tags for PowerShell system paths when as=pathuni
 func TestDryRun_PowerShell_AsPathuni_WithTags(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
-    os.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
     t.Setenv("PATH", "")
 
-    cfg := filepath.Join("/tmp", "pathuni", "home", "Pratt", ".config", "pathuni", "psys-tags.yaml")
-    if err := os.MkdirAll(filepath.Dir(cfg), 0755); err != nil { t.Fatalf("mkdir: %v", err) }
+    cfg := filepath.Join(t.TempDir(), "psys-tags.yaml")
     cfgContent := "macos:\n  powershell:\n    include_system_paths: true\n    include_system_paths_as: pathuni\n    tags: [sys]\n"
     if err := os.WriteFile(cfg, []byte(cfgContent), 0644); err != nil { t.Fatalf("write cfg: %v", err) }
 
@@ -68,13 +66,11 @@ func TestDryRun_PowerShell_AsPathuni_WithTags(t *testing.T) {
 // Stage 3: explicit empty tags break inheritance
 func TestDryRun_PowerShell_AsPathuni_EmptyTagsBreakInheritance(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
-    os.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
     t.Setenv("PATH", "")
 
-    cfg := filepath.Join("/tmp", "pathuni", "home", "Pratt", ".config", "pathuni", "psys-empty-tags.yaml")
-    if err := os.MkdirAll(filepath.Dir(cfg), 0755); err != nil { t.Fatalf("mkdir: %v", err) }
+    cfg := filepath.Join(t.TempDir(), "psys-empty-tags.yaml")
     cfgContent := "macos:\n  tags: [mac]\n  powershell:\n    include_system_paths: true\n    include_system_paths_as: pathuni\n    tags: []\n"
     if err := os.WriteFile(cfg, []byte(cfgContent), 0644); err != nil { t.Fatalf("write cfg: %v", err) }
 
@@ -104,13 +100,11 @@ func TestDryRun_PowerShell_AsPathuni_EmptyTagsBreakInheritance(t *testing.T) {
 // Stage 1: as=pathuni with no tags -> inherit platform tags
 func TestDryRun_PowerShell_AsPathuni_InheritPlatformTags(t *testing.T) {
     setupTestFilesystem(t)
-    defer cleanupTestFilesystem()
 
-    os.Setenv("PATHUNI_TEST_SYSTEM_PATHS_ROOT", filepath.Join("testdata", "system_paths"))
+    withTestRoot(t, filepath.Join("testdata", "system_paths"))
     t.Setenv("PATH", "")
 
-    cfg := filepath.Join("/tmp", "pathuni", "home", "Pratt", ".config", "pathuni", "psys-inherit.yaml")
-    if err := os.MkdirAll(filepath.Dir(cfg), 0755); err != nil { t.Fatalf("mkdir: %v", err) }
+    cfg := filepath.Join(t.TempDir(), "psys-inherit.yaml")
     // powershell.tag omitted -> Tags=nil -> inherit platform [mac]
     cfgContent := "macos:\n  tags: [mac]\n  powershell:\n    include_system_paths: true\n    include_system_paths_as: pathuni\n"
     if err := os.WriteFile(cfg, []byte(cfgContent), 0644); err != nil { t.Fatalf("write cfg: %v", err) }
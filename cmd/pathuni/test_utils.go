@@ -1,76 +1,79 @@
 package main
 
 import (
-	"os"
+	"io/fs"
 	"testing"
+	"testing/fstest"
 )
 
-// setupTestFilesystem creates the test directory structure under /tmp/pathuni/
-// Only creates directories that should exist for testing
-func setupTestFilesystem(t *testing.T) {
+// setupTestFilesystem makes the test directory structure under /tmp/pathuni/
+// the target filesystem, in memory. Only directories that should exist for
+// testing are present; nothing is created on disk.
+func setupTestFilesystem(t *testing.T) fstest.MapFS {
 	t.Helper()
-	
-	// Remove any existing test structure
-	os.RemoveAll("/tmp/pathuni")
-	
-	// Create directories that should exist for testing
+
 	testDirs := []string{
-		"/tmp/pathuni/usr/local/bin",
-		"/tmp/pathuni/usr/bin", 
-		"/tmp/pathuni/usr/sbin",
-		"/tmp/pathuni/usr/games",
-		"/tmp/pathuni/bin",
-		"/tmp/pathuni/sbin", 
-		"/tmp/pathuni/tmp",
-		"/tmp/pathuni/snap/bin",
-		"/tmp/pathuni/opt/games/bin",
-		"/tmp/pathuni/opt/homebrew/bin",
-		"/tmp/pathuni/opt/homebrew/sbin",
-		"/tmp/pathuni/home/Pratt/.local/bin",
-		"/tmp/pathuni/home/Pratt/.cargo/bin",
-		"/tmp/pathuni/home/Pratt/.npm-global/bin",
-		"/tmp/pathuni/home/Pratt/bin",
-		"/tmp/pathuni/home/Pratt/.node_modules/.bin",
-		"/tmp/pathuni/home/Pratt/.config/pathuni",
-		"/tmp/pathuni/home/linuxbrew/.linuxbrew/bin",
-		"/tmp/pathuni/home/linuxbrew/.linuxbrew/sbin",
-		"/tmp/pathuni/Applications/Docker.app/Contents/Resources/bin",
-		"/tmp/pathuni/Applications/Xcode.app/Contents/Developer/usr/bin",
-		"/tmp/pathuni/System/Library/Frameworks",
-		"/tmp/pathuni/usr/local/go/bin",
-		"/tmp/pathuni/usr/local/node/bin",
-		"/tmp/pathuni/opt/dev/bin",
-		"/tmp/pathuni/opt/server/bin",
-		"/tmp/pathuni/opt/work/bin",
-		"/tmp/pathuni/home/user/.cargo/bin",
-		"/tmp/pathuni/opt/server1/bin",
-		"/tmp/pathuni/opt/server2/bin",
-		"/tmp/pathuni/opt/serverless/bin",
-		"/tmp/pathuni/opt/tools",
-		"/tmp/pathuni/home/grunt",
-		"/tmp/pathuni/usr/all/bin",
-		"/tmp/pathuni/opt/app",
-		"/tmp/pathuni/home/aol",
-		"/tmp/pathuni/opt/gaming",
-		"/tmp/pathuni/usr/work",
-		"/tmp/pathuni/home/server",
-		"/tmp/pathuni/tmp/build",
-		"/tmp/pathuni/var/work",
-		"/tmp/pathuni/opt/cache",
+		"tmp/pathuni/usr/local/bin",
+		"tmp/pathuni/usr/bin",
+		"tmp/pathuni/usr/sbin",
+		"tmp/pathuni/usr/games",
+		"tmp/pathuni/bin",
+		"tmp/pathuni/sbin",
+		"tmp/pathuni/tmp",
+		"tmp/pathuni/snap/bin",
+		"tmp/pathuni/opt/games/bin",
+		"tmp/pathuni/opt/homebrew/bin",
+		"tmp/pathuni/opt/homebrew/sbin",
+		"tmp/pathuni/home/Pratt/.local/bin",
+		"tmp/pathuni/home/Pratt/.cargo/bin",
+		"tmp/pathuni/home/Pratt/.npm-global/bin",
+		"tmp/pathuni/home/Pratt/bin",
+		"tmp/pathuni/home/Pratt/.node_modules/.bin",
+		"tmp/pathuni/home/Pratt/.config/pathuni",
+		"tmp/pathuni/home/linuxbrew/.linuxbrew/bin",
+		"tmp/pathuni/home/linuxbrew/.linuxbrew/sbin",
+		"tmp/pathuni/Applications/Docker.app/Contents/Resources/bin",
+		"tmp/pathuni/Applications/Xcode.app/Contents/Developer/usr/bin",
+		"tmp/pathuni/System/Library/Frameworks",
+		"tmp/pathuni/usr/local/go/bin",
+		"tmp/pathuni/usr/local/node/bin",
+		"tmp/pathuni/opt/dev/bin",
+		"tmp/pathuni/opt/server/bin",
+		"tmp/pathuni/opt/work/bin",
+		"tmp/pathuni/home/user/.cargo/bin",
+		"tmp/pathuni/opt/server1/bin",
+		"tmp/pathuni/opt/server2/bin",
+		"tmp/pathuni/opt/serverless/bin",
+		"tmp/pathuni/opt/tools",
+		"tmp/pathuni/home/grunt",
+		"tmp/pathuni/usr/all/bin",
+		"tmp/pathuni/opt/app",
+		"tmp/pathuni/home/aol",
+		"tmp/pathuni/opt/gaming",
+		"tmp/pathuni/usr/work",
+		"tmp/pathuni/home/server",
+		"tmp/pathuni/tmp/build",
+		"tmp/pathuni/var/work",
+		"tmp/pathuni/opt/cache",
 	}
-	
+
+	fsys := fstest.MapFS{}
 	for _, dir := range testDirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			t.Fatalf("Failed to create test directory %s: %v", dir, err)
-		}
+		fsys[dir] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
 	}
-	
-	// Uncomment to inspect filesystem structure:
-	//time.Sleep(100 * time.Second)
-
+	old := rootFS
+	rootFS = fsys
+	t.Cleanup(func() { rootFS = old })
+	return fsys
 }
 
-// cleanupTestFilesystem removes the test directory structure
-func cleanupTestFilesystem() {
-	os.RemoveAll("/tmp/pathuni")
-}
\ No newline at end of file
+// includedPaths returns the paths of the evaluated entries init would use.
+func includedPaths(statuses []PathStatus) []string {
+	var paths []string
+	for _, st := range statuses {
+		if st.Included {
+			paths = append(paths, st.Path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,2 @@
+# Go toolchain
+export PATH=$PATH:/tmp/pathuni/usr/local/go/bin
@@ -0,0 +1,4 @@
+if [ -d /tmp/pathuni/usr/local/node/bin ]; then
+    PATH="/tmp/pathuni/usr/local/node/bin:$PATH"; export PATH
+fi
+PATH=$HOME/.npm/bin:$PATH
@@ -0,0 +1,169 @@
+package main
+
+// Unknown-key checking. The config is decoded through a yaml.Node first so
+// that unknown keys (typos such as `pth:` or `mac:`) are reported with their
+// file, line and column instead of being silently ignored. --lenient skips
+// the check for old configs.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lenient disables unknown-field checking.
+var lenient bool
+
+// nodeSchema describes the keys accepted at one place in the config. A nil
+// *nodeSchema accepts any value.
+type nodeSchema struct {
+	Fields map[string]*nodeSchema // Known keys of a mapping
+	Values *nodeSchema            // Schema for every value of a free-form mapping
+	Items  *nodeSchema            // Schema for mapping items of a sequence
+}
+
+var (
+	positionSchema = &nodeSchema{Fields: map[string]*nodeSchema{"before": nil, "after": nil}}
+
+	pathEntrySchema = &nodeSchema{Fields: map[string]*nodeSchema{
+		"path": nil, "tags": nil, "command": nil, "suffix": nil,
+		"position": positionSchema, "priority": nil, "required": nil,
+	}}
+
+	shellConfigSchema = &nodeSchema{Fields: map[string]*nodeSchema{
+		"include_system_paths": nil, "include_system_paths_as": nil, "system_sources": nil, "tags": nil,
+		"paths": {Items: pathEntrySchema},
+	}}
+
+	platformSchema = &nodeSchema{Fields: map[string]*nodeSchema{
+		"tags": nil, "paths": {Items: pathEntrySchema}, "providers": nil,
+		"remove": nil, "powershell": shellConfigSchema, "shells": {Values: shellConfigSchema},
+		"system_source": nil,
+	}}
+
+	profileSchema = &nodeSchema{Fields: map[string]*nodeSchema{
+		"extends": nil, "tags_include": nil, "tags_exclude": nil,
+		"scope": nil, "prune": nil, "dedupe": nil, "defer_env": nil,
+	}}
+
+	configSchema = &nodeSchema{Fields: map[string]*nodeSchema{
+		"all": platformSchema, "linux": platformSchema, "macos": platformSchema,
+		"defaults": profileSchema, "profiles": {Values: profileSchema}, "vars": nil,
+	}}
+)
+
+// checkConfigNode reports the first unknown key in a parsed config document.
+func checkConfigNode(doc *yaml.Node, file string) error {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		doc = doc.Content[0]
+	}
+	return checkNode(doc, configSchema, "", file)
+}
+
+func checkNode(n *yaml.Node, s *nodeSchema, context, file string) error {
+	if s == nil {
+		return nil
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	switch {
+	case s.Items != nil && n.Kind == yaml.SequenceNode:
+		for i, item := range n.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			if err := checkNode(item, s.Items, fmt.Sprintf("%s[%d]", context, i), file); err != nil {
+				return err
+			}
+		}
+	case n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" {
+				continue // merge keys are checked where the anchor is defined
+			}
+			child := s.Values
+			if s.Values == nil {
+				known, ok := s.Fields[key.Value]
+				if !ok {
+					return unknownFieldError(key, context, file, s.Fields)
+				}
+				child = known
+			}
+			if err := checkNode(value, child, joinContext(context, key.Value), file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinContext(context, key string) string {
+	if context == "" {
+		return key
+	}
+	return context + "." + key
+}
+
+func unknownFieldError(key *yaml.Node, context, file string, fields map[string]*nodeSchema) error {
+	where := "at top level"
+	if context != "" {
+		where = "in " + context
+	}
+	msg := fmt.Sprintf("%s:%d:%d: unknown field %q %s", file, key.Line, key.Column, key.Value, where)
+	if key.Line == 0 {
+		// Documents converted from TOML have no positions
+		msg = fmt.Sprintf("%s: unknown field %q %s", file, key.Value, where)
+	}
+	if suggestion := closestField(key.Value, fields); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// closestField returns the known field nearest to name by edit distance, or
+// "" when none is close enough to be a plausible typo.
+func closestField(name string, fields map[string]*nodeSchema) string {
+	names := make([]string, 0, len(fields))
+	for f := range fields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", 3
+	for _, f := range names {
+		d := editDistance(strings.ToLower(name), f)
+		if d < bestDist && d < len(name) {
+			best, bestDist = f, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
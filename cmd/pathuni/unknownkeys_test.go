@@ -0,0 +1,110 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnknownKeys_UnknownFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "path entry typo",
+			content: "macos:\n  paths:\n    - /usr/bin\n    - path: /opt/a\n    - pth: /opt/b\n      tags: [dev]\n",
+			want:    `:5:7: unknown field "pth" in macos.paths[2] (did you mean "path"?)`,
+		},
+		{
+			name:    "tag instead of tags",
+			content: "all:\n  tag: [dev]\n",
+			want:    `:2:3: unknown field "tag" in all (did you mean "tags"?)`,
+		},
+		{
+			name:    "unknown top-level section",
+			content: "mac:\n  paths: [/usr/bin]\n",
+			want:    `:1:1: unknown field "mac" at top level (did you mean "macos"?)`,
+		},
+		{
+			name:    "position key",
+			content: "all:\n  paths:\n    - path: /opt/a\n      position: {befor: /usr/bin}\n",
+			want:    `unknown field "befor" in all.paths[0].position (did you mean "before"?)`,
+		},
+		{
+			name:    "profile key",
+			content: "profiles:\n  work:\n    scop: full\n",
+			want:    `unknown field "scop" in profiles.work (did you mean "scope"?)`,
+		},
+		{
+			name:    "no close match",
+			content: "linux:\n  powershell:\n    frobnicate: true\n",
+			want:    `unknown field "frobnicate" in linux.powershell`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgPath := filepath.Join(t.TempDir(), "my_paths.yaml")
+			writeFile(t, cfgPath, tt.content)
+
+			_, err := loadConfig(cfgPath)
+			if err == nil || !strings.HasPrefix(err.Error(), cfgPath) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q, got %v", tt.want, err)
+			}
+			if tt.name == "no close match" && strings.Contains(err.Error(), "did you mean") {
+				t.Errorf("unexpected suggestion: %v", err)
+			}
+
+			lenient = true
+			defer func() { lenient = false }()
+			if _, err := loadConfig(cfgPath); err != nil {
+				t.Errorf("lenient load failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnknownKeys_AcceptsFullSchema(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "full.yaml")
+	writeFile(t, cfgPath, `defaults: &defaults
+  prune: all
+profiles:
+  work:
+    <<: *defaults
+    extends: ""
+    tags_include: work
+all:
+  tags: [base]
+  providers: [cargo]
+  remove: [/usr/games]
+  paths:
+    - /usr/bin
+    - path: /opt/a
+      tags: [dev]
+      position: {after: /usr/bin}
+      priority: 2
+    - command: [go, env, GOPATH]
+      suffix: /bin
+macos:
+  powershell:
+    include_system_paths: true
+    include_system_paths_as: pathuni
+    tags: [mac]
+`)
+	if _, err := readConfigFiles(cfgPath); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+}
+
+func TestUnknownKeys_EditDistance(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{{"pth", "path", 1}, {"mac", "macos", 2}, {"", "abc", 3}, {"same", "same", 0}} {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}